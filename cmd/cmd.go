@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
 	"google.golang.org/api/calendar/v3"
@@ -18,8 +19,51 @@ var Out = os.Stdout
 
 type (
 	CmdFunc func(gcal.GCalendar) error
+
+	// LocalCmdFunc is a command running without google credentials
+	LocalCmdFunc func() error
 )
 
+// TemplateFile resolves a template name to a file path.
+// Paths are returned as is, names are looked up in the templates directory
+// with and without the supported extensions
+func TemplateFile(templatesDir, name string) (string, error) {
+	candidates := []string{
+		name,
+		filepath.Join(templatesDir, name),
+	}
+
+	for _, ext := range config.TemplateExtensions {
+		candidates = append(candidates, filepath.Join(templatesDir, name+ext))
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("template `%s` not found in %s", name, templatesDir)
+}
+
+// TemplateFiles lists the names of the template files
+// of the supported formats in the templates directory
+func TemplateFiles(templatesDir string) ([]string, error) {
+	entries, err := os.ReadDir(templatesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && config.IsTemplateFile(entry.Name()) {
+			files = append(files, entry.Name())
+		}
+	}
+
+	return files, nil
+}
+
 func CalSrvLocation(
 	ctx context.Context,
 	gCalendar *gcal.GCalendar,
@@ -30,7 +74,7 @@ func CalSrvLocation(
 		return nil, nil, err
 	}
 
-	tz, err := time.LoadLocation(template.Timezone)
+	tz, err := config.LoadLocation(template.Timezone)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -38,6 +82,22 @@ func CalSrvLocation(
 	return calSrv, tz, nil
 }
 
+// AccountEmail returns the email of the authenticated google account,
+// the id of its primary calendar
+func AccountEmail(ctx context.Context, gCalendar *gcal.GCalendar) (string, error) {
+	calSrv, err := gCalendar.CalendarService(ctx, handleAuthConsent)
+	if err != nil {
+		return "", err
+	}
+
+	primary, err := calSrv.Calendars.Get("primary").Context(ctx).Do()
+	if err != nil {
+		return "", err
+	}
+
+	return primary.Id, nil
+}
+
 func handleAuthConsent(authURL string) (string, error) {
 	fmt.Fprintf(Out, "> Visit the link: %v\n", authURL)
 	return userio.UserIn(bytes.NewBufferString("> Enter auth. code: "))
@@ -2,6 +2,7 @@ package list
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -30,6 +31,9 @@ const (
 
 	eventHost      = "host"
 	eventTypeVideo = "video"
+
+	eventDateFormat = "2006-01-02"
+	allDayFormat    = "Mon Jan 2"
 )
 
 var (
@@ -42,8 +46,20 @@ var (
 	}
 )
 
-func List(email string) cmd.CmdFunc {
-	template := loadTemplate(email)
+// listItem is an event of the json output
+type listItem struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	AllDay   bool      `json:"all_day"`
+	Summary  string    `json:"summary"`
+	Status   string    `json:"status,omitempty"`
+	Video    []string  `json:"video,omitempty"`
+	Location string    `json:"location,omitempty"`
+}
+
+// List prints today's events of the calendar in the `text` or `json` output format
+func List(email, timezone, output string) cmd.CmdFunc {
+	template := loadTemplate(email, timezone)
 	return func(gCalendar gcal.GCalendar) error {
 		ctx := context.Background()
 		calSrv, tz, err := cmd.CalSrvLocation(ctx, &gCalendar, template)
@@ -67,14 +83,23 @@ func List(email string) cmd.CmdFunc {
 			return err
 		}
 
+		if output == config.OutputJSON {
+			return printJSON(events.Items, template.CalID, tz)
+		}
+
+		allDay, timed := splitAllDay(events.Items)
+		if err := printAllDay(allDay, template.CalID, tz); err != nil {
+			return err
+		}
+
 		cursorSet := false
 
-		for _, event := range events.Items {
+		for _, event := range timed {
 			if event.Status == statusCancelled {
 				continue
 			}
 
-			startEnd, err := parseEventTime(event)
+			startEnd, err := parseEventTime(event, tz)
 			if err != nil {
 				return err
 			}
@@ -118,8 +143,92 @@ func List(email string) cmd.CmdFunc {
 	}
 }
 
-func loadTemplate(email string) *config.Template {
-	return &config.Template{CalID: email}
+func loadTemplate(email, timezone string) *config.Template {
+	return &config.Template{CalID: email, Timezone: timezone}
+}
+
+// splitAllDay separates the all-day events from the events with a start time
+func splitAllDay(events []*calendar.Event) (allDay, timed []*calendar.Event) {
+	for _, event := range events {
+		if isAllDay(event) {
+			allDay = append(allDay, event)
+		} else {
+			timed = append(timed, event)
+		}
+	}
+	return allDay, timed
+}
+
+// printAllDay prints the all-day events in a separate section,
+// multi-day events with the inclusive date span
+func printAllDay(events []*calendar.Event, email string, tz *time.Location) error {
+	header := false
+	for _, event := range events {
+		if event.Status == statusCancelled {
+			continue
+		}
+
+		startEnd, err := parseEventTime(event, tz)
+		if err != nil {
+			return err
+		}
+
+		emoji, err := statusEmoji(eventResponseStatus(event, email))
+		if err != nil {
+			return err
+		}
+
+		if !header {
+			fmt.Printf("\nAll-day\n-------------------\n")
+			header = true
+		}
+
+		span := startEnd[0].Format(allDayFormat)
+		if lastDay := startEnd[1].AddDate(0, 0, -1); lastDay.After(startEnd[0]) {
+			span += " - " + lastDay.Format(allDayFormat)
+		}
+
+		fmt.Printf("   %s %s: %s\n", emoji, span, event.Summary)
+	}
+
+	return nil
+}
+
+func printJSON(events []*calendar.Event, email string, tz *time.Location) error {
+	items := make([]listItem, 0, len(events))
+	for _, event := range events {
+		if event.Status == statusCancelled {
+			continue
+		}
+
+		startEnd, err := parseEventTime(event, tz)
+		if err != nil {
+			return err
+		}
+
+		item := listItem{
+			Start:    startEnd[0],
+			End:      startEnd[1],
+			AllDay:   isAllDay(event),
+			Summary:  event.Summary,
+			Status:   eventResponseStatus(event, email),
+			Location: event.Location,
+		}
+
+		if event.ConferenceData != nil {
+			for _, conf := range event.ConferenceData.EntryPoints {
+				if conf.EntryPointType == eventTypeVideo {
+					item.Video = append(item.Video, conf.Uri)
+				}
+			}
+		}
+
+		items = append(items, item)
+	}
+
+	enc := json.NewEncoder(cmd.Out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(items)
 }
 
 func eventResponseStatus(event *calendar.Event, email string) string {
@@ -136,10 +245,22 @@ func eventResponseStatus(event *calendar.Event, email string) string {
 	return ""
 }
 
-func parseEventTime(event *calendar.Event) ([]time.Time, error) {
+// parseEventTime returns the start and end of the event,
+// the dates of all-day events are midnights in the given location
+func parseEventTime(event *calendar.Event, tz *time.Location) ([]time.Time, error) {
 	startEnd := make([]time.Time, 0, 2)
 	for _, dateTime := range []*calendar.EventDateTime{event.Start, event.End} {
-		parsed, err := time.Parse(time.RFC3339, dateTime.DateTime)
+		var (
+			parsed time.Time
+			err    error
+		)
+
+		if dateTime.DateTime == "" {
+			parsed, err = time.ParseInLocation(eventDateFormat, dateTime.Date, tz)
+		} else {
+			parsed, err = time.Parse(time.RFC3339, dateTime.DateTime)
+		}
+
 		if err != nil {
 			return nil, err
 		}
@@ -149,6 +270,10 @@ func parseEventTime(event *calendar.Event) ([]time.Time, error) {
 	return startEnd, nil
 }
 
+func isAllDay(event *calendar.Event) bool {
+	return event.Start != nil && event.Start.DateTime == "" && event.Start.Date != ""
+}
+
 func statusEmoji(status string) (string, error) {
 	code, ok := emojiStatus[status]
 	if !ok {
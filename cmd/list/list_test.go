@@ -0,0 +1,70 @@
+package list
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestParseEventTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		event      *calendar.Event
+		wantStart  time.Time
+		wantEnd    time.Time
+		wantAllDay bool
+	}{
+		{
+			name: "timed event",
+			event: &calendar.Event{
+				Start: &calendar.EventDateTime{DateTime: "2026-10-24T09:00:00+02:00"},
+				End:   &calendar.EventDateTime{DateTime: "2026-10-24T17:00:00+02:00"},
+			},
+			wantStart: time.Date(2026, 10, 24, 9, 0, 0, 0, berlin),
+			wantEnd:   time.Date(2026, 10, 24, 17, 0, 0, 0, berlin),
+		},
+		{
+			name: "all-day event",
+			event: &calendar.Event{
+				Start: &calendar.EventDateTime{Date: "2026-12-25"},
+				End:   &calendar.EventDateTime{Date: "2026-12-26"},
+			},
+			wantStart:  time.Date(2026, 12, 25, 0, 0, 0, 0, berlin),
+			wantEnd:    time.Date(2026, 12, 26, 0, 0, 0, 0, berlin),
+			wantAllDay: true,
+		},
+		{
+			name: "multi-day event across the dst transition",
+			event: &calendar.Event{
+				Start: &calendar.EventDateTime{Date: "2026-10-24"},
+				End:   &calendar.EventDateTime{Date: "2026-10-27"},
+			},
+			wantStart:  time.Date(2026, 10, 24, 0, 0, 0, 0, berlin),
+			wantEnd:    time.Date(2026, 10, 27, 0, 0, 0, 0, berlin),
+			wantAllDay: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			startEnd, err := parseEventTime(tt.event, berlin)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !startEnd[0].Equal(tt.wantStart) || !startEnd[1].Equal(tt.wantEnd) {
+				t.Errorf("got %s - %s, want %s - %s", startEnd[0], startEnd[1], tt.wantStart, tt.wantEnd)
+			}
+
+			if got := isAllDay(tt.event); got != tt.wantAllDay {
+				t.Errorf("all-day: got %t, want %t", got, tt.wantAllDay)
+			}
+		})
+	}
+}
@@ -3,44 +3,136 @@ package plan
 import (
 	"bytes"
 	"context"
+	"flag"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"google.golang.org/api/calendar/v3"
+
 	"github.com/makarski/gcaler/cmd"
 	"github.com/makarski/gcaler/config"
+	"github.com/makarski/gcaler/cursor"
 	gcal "github.com/makarski/gcaler/google/calendar"
+	"github.com/makarski/gcaler/planweek"
+	"github.com/makarski/gcaler/rrule"
 	"github.com/makarski/gcaler/staff"
 	"github.com/makarski/gcaler/userio"
 )
 
-func Plan(templatesDir string) cmd.CmdFunc {
-	template, err := loadTemplate(templatesDir)
+const startFormat = "2006-01-02T15:04"
+
+type options struct {
+	template string
+	start    string
+	assign   string
+	yes      bool
+}
+
+// Plan schedules the events of a template, the defaults are set
+// for the calendar and timezone missing in the template.
+// Every value not provided in args is prompted for, so that
+// the plan can run both interactively and without a TTY
+func Plan(templatesDir, cacheDir string, defaults config.Defaults, args []string) cmd.CmdFunc {
+	opts, err := parseOptions(args)
+	if err != nil {
+		return func(gcal.GCalendar) error { return err }
+	}
+
+	template, err := loadTemplate(templatesDir, opts.template, defaults)
 	return func(gCalendar gcal.GCalendar) error {
 		if err != nil {
 			return err
 		}
 
+		for _, warning := range template.Warnings {
+			fmt.Fprintf(cmd.Out, "> Warning: %s\n", warning)
+		}
+
 		ctx := context.Background()
 		calSrv, tz, err := cmd.CalSrvLocation(ctx, &gCalendar, template)
 		if err != nil {
 			return err
 		}
 
-		assignments, err := staff.Assignees(template.Participants).Schedule(
+		cursorStore := cursor.NewStore(filepath.Join(cacheDir, "rotations"))
+		lastShift, err := cursorStore.Load(template.Name)
+		if err != nil {
+			return err
+		}
+
+		input, err := opts.input(tz)
+		if err != nil {
+			return err
+		}
+
+		history := staff.History{Last: lastShift}
+		participants := staff.Assignees(template.Participants)
+		isHoliday := template.Recurrence.IsHoliday
+
+		if template.Rotation.IsFair() {
+			now := time.Now()
+			since := now.Add(-template.HistoryWindow)
+			pastShifts, err := gCalendar.ShiftHistory(ctx, calSrv, template, since, now)
+			if err != nil {
+				return err
+			}
+
+			history.Tally = staff.NewTally(participants, pastShifts, isHoliday)
+
+			fmt.Fprintf(cmd.Out, "> Shifts since %s:\n", since.Format("2006-01-02"))
+			history.Tally.Print(cmd.Out, participants)
+			fmt.Fprintln(cmd.Out)
+		}
+
+		assignments, skipped, err := participants.Schedule(
 			ctx,
 			tz,
-			&template.Recurrence,
+			template,
+			history,
+			input,
 		)
 		if err != nil {
 			return err
 		}
 
+		busyFn := func(emails []string, start, end time.Time) (staff.Busy, error) {
+			return gCalendar.Busy(ctx, calSrv, emails, start, end)
+		}
+
+		assignments, conflicts, err := participants.ResolveConflicts(
+			assignments,
+			template,
+			busyFn,
+		)
+		if err != nil {
+			return err
+		}
+
+		staff.Link(assignments)
+
+		if err := checkRooms(assignments, template, busyFn); err != nil {
+			return err
+		}
+
+		if template.Recurrence.Mode.IsSeries() {
+			confirmed, err := previewRecurrence(assignments, template, opts.yes)
+			if err != nil {
+				return err
+			}
+
+			if !confirmed {
+				fmt.Fprintln(cmd.Out, "Aborted.")
+				return nil
+			}
+		}
+
 		summary := summaryTxtBuffer(len(assignments))
 
-		for _, assignment := range assignments {
+		for i, assignment := range assignments {
 			event, err := gCalendar.CalendarEvent(
 				assignment,
 				template,
@@ -49,10 +141,28 @@ func Plan(templatesDir string) cmd.CmdFunc {
 				return err
 			}
 
-			if _, err := calSrv.Events.Insert(template.CalID, event).Do(); err != nil {
+			insert := calSrv.Events.Insert(template.CalID, event)
+			if event.ConferenceData != nil {
+				insert = insert.ConferenceDataVersion(1)
+			}
+
+			if len(event.Attachments) > 0 {
+				insert = insert.SupportsAttachments(true)
+			}
+
+			created, err := insert.Do()
+			if err != nil {
 				return err
 			}
 
+			// advance the rotation with every inserted event, so that a failed
+			// insert does not make the next plan reassign the created shifts
+			if template.Recurrence.Mode.IsSingle() {
+				if err := cursorStore.Save(staff.Cursor(template.Name, assignments[:i+1])); err != nil {
+					return err
+				}
+			}
+
 			for _, asgnee := range assignment.Assignees {
 				fmt.Fprintf(
 					summary,
@@ -61,6 +171,22 @@ func Plan(templatesDir string) cmd.CmdFunc {
 					assignment.Date.Format(time.RFC1123),
 				)
 			}
+
+			if template.Recurrence.Mode.IsStaggered() {
+				fmt.Fprintf(summary, "    %s\n", strings.Join(event.Recurrence, " "))
+			}
+
+			if template.Conference != "" {
+				fmt.Fprintf(summary, "    %s\n", joinLink(created))
+			}
+		}
+
+		printConflicts(summary, conflicts)
+		printSkipped(summary, skipped)
+
+		if template.Rotation.IsFair() {
+			fmt.Fprintf(summary, "\nShifts including the plan:\n")
+			history.Tally.With(assignments, isHoliday).Print(summary, participants)
 		}
 
 		_, err = io.Copy(cmd.Out, summary)
@@ -68,6 +194,137 @@ func Plan(templatesDir string) cmd.CmdFunc {
 	}
 }
 
+// checkRooms verifies that the rooms are free during the planned events,
+// recurring series are checked for their first occurrences.
+// Rooms which could not be read are reported and not checked
+func checkRooms(assignments []staff.Assignment, template *config.Template, busyFn staff.BusyFunc) error {
+	if len(template.Rooms) == 0 {
+		return nil
+	}
+
+	periods := make([]staff.Period, 0, len(assignments))
+	for _, assignment := range assignments {
+		assignmentPeriods, err := assignment.Periods(template)
+		if err != nil {
+			return err
+		}
+		periods = append(periods, assignmentPeriods...)
+	}
+
+	conflicts, err := staff.RoomConflicts(template.Rooms, periods, busyFn)
+	if err != nil {
+		return err
+	}
+
+	booked := make([]staff.RoomConflict, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		if conflict.Unreadable != "" {
+			fmt.Fprintf(cmd.Out, "  ? %s calendar could not be read: %s - not checked\n", conflict.Room, conflict.Unreadable)
+			continue
+		}
+		booked = append(booked, conflict)
+	}
+
+	if len(booked) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(cmd.Out, "\nBooked rooms: %d\n-------------------\n", len(booked))
+	for _, conflict := range booked {
+		fmt.Fprintf(cmd.Out, "  ! %s is booked: %s\n", conflict.Room, conflict.Start.Format(time.RFC1123))
+	}
+
+	return fmt.Errorf("rooms are booked during %d planned events, no events created", len(booked))
+}
+
+// previewRecurrence prints the first occurrences of the recurring
+// series expanded locally and asks for a confirmation to insert them
+func previewRecurrence(assignments []staff.Assignment, template *config.Template, confirmed bool) (bool, error) {
+	var preview bytes.Buffer
+	for _, assignment := range assignments {
+		rules, err := assignment.SeriesOf(template).RFC5545(assignment.Date)
+		if err != nil {
+			return false, err
+		}
+
+		set, err := rrule.Parse(assignment.Date, rules)
+		if err != nil {
+			return false, err
+		}
+
+		fmt.Fprintf(&preview, "> %s: %s\n", assignment.Assignees.Names(), strings.Join(rules, " "))
+		fmt.Fprintf(&preview, "> First %d occurrences:\n", staff.SeriesPreview)
+		for _, date := range set.Occurrences(staff.SeriesPreview) {
+			fmt.Fprintf(&preview, "  * %s\n", date.Format(time.RFC1123))
+		}
+		preview.WriteString("\n")
+	}
+
+	if confirmed {
+		_, err := io.Copy(cmd.Out, &preview)
+		return true, err
+	}
+
+	preview.WriteString("> Insert the recurring series?")
+	return userio.UserInBool(&preview)
+}
+
+func parseOptions(args []string) (*options, error) {
+	var opts options
+
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	fs.StringVar(&opts.template, "template", "", "Template name or path, prompted if empty")
+	fs.StringVar(&opts.start, "start", "", "First event date and time in the template timezone, ex: 2026-11-02T09:00; prompted if empty")
+	fs.StringVar(&opts.assign, "assign", "", "Manual rotation assignees per planned date separated by commas, group members by spaces: participant indexes or emails, ex: \"0,1 2,alice@host.example\"; prompted if empty")
+	fs.BoolVar(&opts.yes, "yes", false, "Insert recurring series without a confirmation")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("plan: unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	return &opts, nil
+}
+
+// input converts the options to the schedule input
+func (o *options) input(tz *time.Location) (staff.Input, error) {
+	var input staff.Input
+
+	if o.start != "" {
+		start, err := time.ParseInLocation(startFormat, o.start, tz)
+		if err != nil {
+			return input, fmt.Errorf("invalid `-start` value: %s, expected format: %s", o.start, startFormat)
+		}
+		input.Start = &start
+	}
+
+	if o.assign != "" {
+		for _, picks := range strings.Split(o.assign, ",") {
+			input.Picks = append(input.Picks, strings.Fields(picks))
+		}
+	}
+
+	return input, nil
+}
+
+// joinLink returns the conference link of the created event,
+// the conference may still be in creation
+func joinLink(event *calendar.Event) string {
+	if link := gcal.JoinLink(event); link != "" {
+		return link
+	}
+
+	if event.ConferenceData != nil && event.ConferenceData.CreateRequest != nil &&
+		event.ConferenceData.CreateRequest.Status != nil {
+		return "conference " + event.ConferenceData.CreateRequest.Status.StatusCode
+	}
+
+	return "conference not created"
+}
+
 func summaryTxtBuffer(countAssgnmts int) *bytes.Buffer {
 	var summary bytes.Buffer
 	fmt.Fprintf(
@@ -81,8 +338,57 @@ Events created: %d
 	return &summary
 }
 
-func loadTemplate(templatesDir string) (*config.Template, error) {
-	templateCfgs, err := os.ReadDir(templatesDir)
+func printConflicts(w io.Writer, conflicts []staff.Conflict) {
+	if len(conflicts) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\nConflicts: %d\n-------------------\n", len(conflicts))
+	for _, conflict := range conflicts {
+		if conflict.Unreadable != "" {
+			fmt.Fprintf(w, "  ? %s calendar could not be read: %s - not checked\n", conflict.Assignee.FullName(), conflict.Unreadable)
+			continue
+		}
+
+		resolution := "kept"
+		switch {
+		case conflict.Skipped:
+			resolution = "skipped"
+		case conflict.Replacement != nil:
+			resolution = "reassigned to " + conflict.Replacement.FullName()
+		}
+
+		fmt.Fprintf(
+			w,
+			"  ! %s is busy: %s - %s\n",
+			conflict.Assignee.FullName(),
+			conflict.Date.Format(time.RFC1123),
+			resolution,
+		)
+	}
+}
+
+func printSkipped(w io.Writer, skipped []planweek.Skipped) {
+	if len(skipped) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\nSkipped dates: %d\n-------------------\n", len(skipped))
+	for _, s := range skipped {
+		fmt.Fprintf(w, "  - %s: %s\n", s.Date.Format("2006-01-02 (Mon)"), s.Reason)
+	}
+}
+
+func loadTemplate(templatesDir, name string, defaults config.Defaults) (*config.Template, error) {
+	if name != "" {
+		file, err := cmd.TemplateFile(templatesDir, name)
+		if err != nil {
+			return nil, err
+		}
+		return config.LoadTemplate(file, defaults)
+	}
+
+	templateCfgs, err := cmd.TemplateFiles(templatesDir)
 	if err != nil {
 		return nil, err
 	}
@@ -96,7 +402,7 @@ func loadTemplate(templatesDir string) (*config.Template, error) {
 	fmt.Fprintf(&stdOutTemplate, "> Select a template [0..%d]\n", len(templateCfgs)-1)
 
 	for i, templateFile := range templateCfgs {
-		fmt.Fprintf(&stdOutTemplate, "  * %d: %s\n", i, templateFile.Name())
+		fmt.Fprintf(&stdOutTemplate, "  * %d: %s\n", i, templateFile)
 	}
 
 	stdOutTemplate.WriteString("\n> Template: ")
@@ -106,6 +412,10 @@ func loadTemplate(templatesDir string) (*config.Template, error) {
 		return nil, err
 	}
 
-	templateFile := filepath.Join(templatesDir, templateCfgs[templateIndex].Name())
-	return config.LoadTemplate(templateFile)
+	if templateIndex < 0 || templateIndex >= len(templateCfgs) {
+		return nil, fmt.Errorf("invalid template index: %d", templateIndex)
+	}
+
+	templateFile := filepath.Join(templatesDir, templateCfgs[templateIndex])
+	return config.LoadTemplate(templateFile, defaults)
 }
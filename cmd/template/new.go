@@ -0,0 +1,336 @@
+package template
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml"
+
+	"github.com/makarski/gcaler/cmd"
+	"github.com/makarski/gcaler/config"
+	"github.com/makarski/gcaler/userio"
+)
+
+// AccountEmailFunc returns the email of the authenticated google account
+type AccountEmailFunc func() (string, error)
+
+// newTemplate walks through the template fields and writes
+// the answers to `{templatesDir}/{name}.toml` once they form a valid template.
+// The defaults are offered for the calendar id and the timezone
+func newTemplate(templatesDir, name string, defaults config.Defaults, accountEmail AccountEmailFunc) cmd.LocalCmdFunc {
+	return func() error {
+		file := filepath.Join(templatesDir, name+".toml")
+		if _, err := os.Stat(file); err == nil {
+			return fmt.Errorf("template %s already exists", file)
+		}
+
+		values, err := promptTemplate(name, defaults, accountEmail)
+		if err != nil {
+			return err
+		}
+
+		tree, err := toml.TreeFromMap(values)
+		if err != nil {
+			return err
+		}
+
+		content, err := tree.ToTomlString()
+		if err != nil {
+			return err
+		}
+
+		if err := writeTemplate(file, content); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.Out, "> Template written to %s\n", file)
+		return nil
+	}
+}
+
+// writeTemplate validates the content in a temporary file next to the target,
+// so that relative paths resolve the same way, and renames it on success
+func writeTemplate(file, content string) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file), "."+strings.TrimSuffix(filepath.Base(file), ".toml")+"-*.toml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// the written template holds every prompted value and is valid on its own
+	if _, err := config.LoadTemplate(tmp.Name(), config.Defaults{}); err != nil {
+		return fmt.Errorf("%s: %s", file, err)
+	}
+
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
+
+func promptTemplate(name string, defaults config.Defaults, accountEmail AccountEmailFunc) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+
+	timezone := defaults.Timezone
+	if timezone == "" {
+		timezone = "Local"
+	}
+
+	fields := []struct {
+		key          string
+		prompt       string
+		defaultValue string
+		validate     func(string) error
+	}{
+		{"name", "Rotation name", name, required},
+		{"cal_id", "Calendar id", defaults.CalID, required},
+		{"event_title", "Event title", name, required},
+		{"timezone", "Timezone", timezone, validateTimezone},
+		{"duration", "Event duration", "8h", validateDuration},
+		{"transparency", "Transparency (busy, free)", "busy", oneOf("busy", "free")},
+		{"visibility", "Visibility (public, private)", "public", oneOf("public", "private")},
+		{"rotation", "Rotation (manual, round_robin, fair)", string(config.RotationRoundRobin), oneOf(
+			string(config.RotationManual),
+			string(config.RotationRoundRobin),
+			string(config.RotationFair),
+		)},
+		{"description", "Event description", "", nil},
+	}
+
+	for _, field := range fields {
+		value, err := prompt(field.prompt, field.defaultValue, field.validate)
+		if err != nil {
+			return nil, err
+		}
+
+		if value != "" {
+			values[field.key] = value
+		}
+	}
+
+	host, err := promptHost(accountEmail)
+	if err != nil {
+		return nil, err
+	}
+	values["host"] = host
+
+	participants, err := promptParticipants()
+	if err != nil {
+		return nil, err
+	}
+	values["participants"] = participants
+
+	recurrence, err := promptRecurrence()
+	if err != nil {
+		return nil, err
+	}
+	values["recurrence"] = recurrence
+
+	return values, nil
+}
+
+func promptHost(accountEmail AccountEmailFunc) (map[string]interface{}, error) {
+	var email string
+
+	if accountEmail != nil {
+		useAccount, err := userio.UserInBool(bytes.NewBufferString("> Use the authenticated google account as host"))
+		if err != nil {
+			return nil, err
+		}
+
+		if useAccount {
+			if email, err = accountEmail(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	email, err := prompt("Host email", email, config.ValidateEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	return promptPerson("Host", email)
+}
+
+func promptParticipants() ([]map[string]interface{}, error) {
+	participants := make([]map[string]interface{}, 0)
+
+	for {
+		email, err := prompt(fmt.Sprintf("Participant %d email (empty to finish)", len(participants)+1), "", func(value string) error {
+			if value == "" {
+				return nil
+			}
+			return config.ValidateEmail(value)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if email == "" {
+			if len(participants) == 0 {
+				fmt.Fprintln(cmd.Out, "  at least one participant is required")
+				continue
+			}
+			return participants, nil
+		}
+
+		participant, err := promptPerson(fmt.Sprintf("Participant %d", len(participants)+1), email)
+		if err != nil {
+			return nil, err
+		}
+
+		participants = append(participants, participant)
+	}
+}
+
+func promptPerson(label, email string) (map[string]interface{}, error) {
+	firstName, err := prompt(label+" first name", "", required)
+	if err != nil {
+		return nil, err
+	}
+
+	lastName, err := prompt(label+" last name", "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"email":      email,
+		"first_name": firstName,
+		"last_name":  lastName,
+	}, nil
+}
+
+func promptRecurrence() (map[string]interface{}, error) {
+	mode, err := prompt("Recurrence mode (single, recurrent, staggered)", string(config.RecModeSingle), oneOf(
+		string(config.RecModeSingle),
+		string(config.RecModeRecurrent),
+		string(config.RecModeStaggered),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	freq, err := prompt("Frequency (daily, weekly, monthly, yearly)", string(config.FreqWeekly), oneOf(
+		string(config.FreqDaily),
+		string(config.FreqWeekly),
+		string(config.FreqMonthly),
+		string(config.FreqYearly),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	interval, err := promptInt("Interval", "1", false)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := promptInt("Count (-1 for unlimited)", "1", true)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"mode":     mode,
+		"freq":     freq,
+		"interval": interval,
+		"count":    count,
+	}, nil
+}
+
+// prompt repeats the question until the answer is valid
+func prompt(question, defaultValue string, validate func(string) error) (string, error) {
+	for {
+		value, err := userio.UserInDefault(bytes.NewBufferString("> "+question), defaultValue)
+		if err != nil {
+			return "", err
+		}
+
+		if validate == nil {
+			return value, nil
+		}
+
+		if err := validate(value); err != nil {
+			fmt.Fprintf(cmd.Out, "  %s\n", err)
+			continue
+		}
+
+		return value, nil
+	}
+}
+
+// promptInt asks for a positive number, or -1 if `unlimited` is allowed
+func promptInt(question, defaultValue string, unlimited bool) (int64, error) {
+	value, err := prompt(question, defaultValue, func(value string) error {
+		n, err := strconv.Atoi(value)
+		switch {
+		case err == nil && n >= 1:
+			return nil
+		case err == nil && n == -1 && unlimited:
+			return nil
+		case unlimited:
+			return errors.New("a positive number or -1 is expected")
+		}
+		return errors.New("a positive number is expected")
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func required(value string) error {
+	if value == "" {
+		return errors.New("a value is required")
+	}
+	return nil
+}
+
+func oneOf(valid ...string) func(string) error {
+	return func(value string) error {
+		for _, v := range valid {
+			if value == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("valid values: %s", strings.Join(valid, ", "))
+	}
+}
+
+func validateTimezone(value string) error {
+	_, err := config.ResolveTimezone(value)
+	return err
+}
+
+func validateDuration(value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+
+	if d <= 0 {
+		return errors.New("a positive duration is expected")
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package template
+
+import (
+	"fmt"
+
+	"github.com/makarski/gcaler/cmd"
+	"github.com/makarski/gcaler/config"
+)
+
+const (
+	showCmdName = "show"
+	newCmdName  = "new"
+)
+
+// Template returns the template subcommand selected by args.
+// `show {name}` prints the template merged with the templates it extends,
+// `new {name}` creates a template interactively
+func Template(templatesDir string, defaults config.Defaults, args []string, accountEmail AccountEmailFunc) (cmd.LocalCmdFunc, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("template: subcommand expected: %s, %s", showCmdName, newCmdName)
+	}
+
+	switch args[0] {
+	case showCmdName:
+		if len(args) != 2 {
+			return nil, fmt.Errorf("template %s: template name expected", showCmdName)
+		}
+		return show(templatesDir, args[1], defaults), nil
+	case newCmdName:
+		if len(args) != 2 {
+			return nil, fmt.Errorf("template %s: template name expected", newCmdName)
+		}
+		return newTemplate(templatesDir, args[1], defaults, accountEmail), nil
+	}
+
+	return nil, fmt.Errorf("template: subcommand `%s` not found", args[0])
+}
+
+func show(templatesDir, name string, defaults config.Defaults) cmd.LocalCmdFunc {
+	return func() error {
+		file, err := cmd.TemplateFile(templatesDir, name)
+		if err != nil {
+			return err
+		}
+
+		tree, err := config.LoadTemplateTree(file)
+		if err != nil {
+			return err
+		}
+
+		effective, err := tree.ToTomlString()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.Out, "# %s\n%s", file, effective)
+
+		// report the validation errors of the merged template
+		_, err = config.LoadTemplate(file, defaults)
+		return err
+	}
+}
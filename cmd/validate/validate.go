@@ -0,0 +1,71 @@
+package validate
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/makarski/gcaler/cmd"
+	"github.com/makarski/gcaler/config"
+)
+
+// Validate loads the named templates, or every template in the templates directory,
+// and reports the problems of each file. An error is returned if any template is invalid.
+// The user config defaults are not applied, so that the result does not depend on who runs it
+func Validate(templatesDir string, names []string) cmd.LocalCmdFunc {
+	return func() error {
+		files, err := templateFiles(templatesDir, names)
+		if err != nil {
+			return err
+		}
+
+		invalid := 0
+		for _, file := range files {
+			template, err := config.LoadTemplate(file, config.Defaults{})
+			if err != nil {
+				invalid++
+				for _, line := range strings.Split(err.Error(), "\n") {
+					fmt.Fprintf(cmd.Out, "%s: %s\n", file, line)
+				}
+				continue
+			}
+
+			for _, warning := range template.Warnings {
+				fmt.Fprintf(cmd.Out, "%s: warning: %s\n", file, warning)
+			}
+		}
+
+		if invalid > 0 {
+			return fmt.Errorf("invalid templates: %d of %d", invalid, len(files))
+		}
+
+		fmt.Fprintf(cmd.Out, "valid templates: %d\n", len(files))
+		return nil
+	}
+}
+
+func templateFiles(templatesDir string, names []string) ([]string, error) {
+	if len(names) > 0 {
+		files := make([]string, 0, len(names))
+		for _, name := range names {
+			file, err := cmd.TemplateFile(templatesDir, name)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, file)
+		}
+		return files, nil
+	}
+
+	names, err := cmd.TemplateFiles(templatesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(names))
+	for _, name := range names {
+		files = append(files, filepath.Join(templatesDir, name))
+	}
+
+	return files, nil
+}
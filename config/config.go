@@ -3,16 +3,48 @@ package config
 import (
 	"errors"
 	"fmt"
-	"os"
+	"net/mail"
+	"net/url"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/pelletier/go-toml"
+	"github.com/makarski/gcaler/holidays"
+	"github.com/makarski/gcaler/planweek"
 )
 
 const (
 	RecModeSingle    RecMode = "single"
 	RecModeRecurrent RecMode = "recurrent"
+	RecModeStaggered RecMode = "staggered"
+
+	FreqMinutely Freq = "minutely"
+	FreqHourly   Freq = "hourly"
+	FreqDaily    Freq = "daily"
+	FreqWeekly   Freq = "weekly"
+	FreqMonthly  Freq = "monthly"
+	FreqYearly   Freq = "yearly"
+
+	RotationManual     Rotation = "manual"
+	RotationRoundRobin Rotation = "round_robin"
+	RotationFair       Rotation = "fair"
+
+	ConflictWarn     ConflictPolicy = "warn"
+	ConflictSkip     ConflictPolicy = "skip"
+	ConflictReassign ConflictPolicy = "reassign"
+
+	ConferenceMeet = "hangoutsMeet"
+
+	dateFormat = "2006-01-02"
+
+	day = 24 * time.Hour
+
+	// maxColorID and maxAttachments are the google calendar limits
+	maxColorID     = 11
+	maxAttachments = 25
+
+	defaultHistoryWindow = 365 * day
 )
 
 type (
@@ -23,60 +55,183 @@ type (
 
 	// Template holds calendar event basic configuration data
 	Template struct {
-		CalID                 string        `toml:"cal_id"`
-		Name                  string        `toml:"name"`
-		EventTitle            string        `toml:"event_title"`
-		Timezone              string        `toml:"timezone"`
-		Transparency          string        `toml:"transparency"`
-		Visibility            string        `toml:"visibility"`
-		Participants          []*Assignee   `toml:"participants"`
-		EventHost             Assignee      `toml:"host"`
-		Duration              time.Duration `toml:"duration"`
-		Recurrence            Recurrence    `toml:"recurrence"`
-		Description           string        `toml:"description"`
-		TitleWithParticipants bool          `toml:"title_with_participants"`
+		Roster                string         `toml:"roster"`
+		CalID                 string         `toml:"cal_id"`
+		Name                  string         `toml:"name"`
+		EventTitle            string         `toml:"event_title"`
+		Timezone              string         `toml:"timezone"`
+		Transparency          string         `toml:"transparency"`
+		Visibility            string         `toml:"visibility"`
+		Participants          []*Assignee    `toml:"participants"`
+		EventHost             Assignee       `toml:"host"`
+		Duration              time.Duration  `toml:"duration"`
+		Recurrence            Recurrence     `toml:"recurrence"`
+		Description           string         `toml:"description"`
+		TitleWithParticipants bool           `toml:"title_with_participants"`
+		Rotation              Rotation       `toml:"rotation"`
+		GroupSize             int            `toml:"group_size"`
+		HistoryWindow         time.Duration  `toml:"history_window"`
+		OnConflict            ConflictPolicy `toml:"on_conflict"`
+		Reminders             []*Reminder    `toml:"reminders"`
+		Conference            string         `toml:"conference"`
+		AllDay                bool           `toml:"all_day"`
+		Location              string         `toml:"location"`
+		ColorID               string         `toml:"color_id"`
+		Attachments           []string       `toml:"attachments"`
+
+		// Attendees are invited to every event in addition to the assignees,
+		// Rooms are the resource calendars booked for the events
+		Attendees []*Attendee `toml:"attendees"`
+		Rooms     []string    `toml:"rooms"`
+
+		GuestsCanModify         bool  `toml:"guests_can_modify"`
+		GuestsCanInviteOthers   *bool `toml:"guests_can_invite_others"`
+		GuestsCanSeeOtherGuests *bool `toml:"guests_can_see_other_guests"`
+
+		// Warnings collects the deprecation notices of the loaded template
+		Warnings []string `toml:"-"`
 	}
 
 	// Assignee describes a config `people` item entry
 	Assignee struct {
-		FirstName   string `toml:"first_name"`
-		LastName    string `toml:"last_name"`
-		Email       string `toml:"email"`
-		Description string `toml:"description"`
+		FirstName   string            `toml:"first_name"`
+		LastName    string            `toml:"last_name"`
+		Email       string            `toml:"email"`
+		Description string            `toml:"description"`
+		Unavailable []*Unavailability `toml:"unavailable"`
+		// Reminders override the template reminders of the assignee events
+		Reminders []*Reminder `toml:"reminders"`
+		// ColorID overrides the template event color of the assignee events
+		ColorID string `toml:"color_id"`
+		// Optional marks the assignee attendance as optional
+		Optional bool `toml:"optional"`
+	}
+
+	// Attendee describes a static event guest: an observer or a mailing list
+	Attendee struct {
+		Email    string `toml:"email"`
+		Name     string `toml:"name"`
+		Optional bool   `toml:"optional"`
+	}
+
+	// Unavailability describes when an assignee can not take shifts:
+	// an inclusive date range, recurring weekdays or weekdays within a range
+	Unavailability struct {
+		From     string   `toml:"from"`
+		To       string   `toml:"to"`
+		Weekdays []string `toml:"weekdays"`
 	}
 
 	Recurrence struct {
-		Mode      RecMode       `toml:"mode"`
-		Count     int32         `toml:"count"`
-		Frequency time.Duration `toml:"frequency"`
-		Interval  uint32        `toml:"interval"`
+		Mode     RecMode  `toml:"mode"`
+		Count    int32    `toml:"count"`
+		Freq     Freq     `toml:"freq"`
+		Interval uint32   `toml:"interval"`
+		Holidays []string `toml:"holidays"`
+
+		// Frequency is deprecated in favour of Freq and Interval
+		Frequency planweek.Step `toml:"frequency"`
+
+		SkipWeekends bool     `toml:"skip_weekends"`
+		Weekdays     []string `toml:"weekdays"`
+		HolidaysFile string   `toml:"holidays_file"`
+
+		ByDay      []string `toml:"by_day"`
+		ByMonthDay []int    `toml:"by_month_day"`
+		BySetPos   []int    `toml:"by_set_pos"`
+		Until      string   `toml:"until"`
+		Wkst       string   `toml:"wkst"`
+		ExDates    []string `toml:"exdates"`
+		RDates     []string `toml:"rdates"`
+
+		// allDay formats the rule dates as dates of the all-day events
+		allDay bool
+
+		// holidays merges `holidays` and `holidays_file` dates
+		holidays holidays.Holidays
 	}
 
 	RecMode string
+
+	// Freq is the recurrence frequency unit, repeated every `interval` units
+	// by both single events and recurring series
+	Freq string
+
+	// Rotation defines how participants are assigned to the planned dates
+	Rotation string
+
+	// ConflictPolicy defines how shifts overlapping
+	// with the assignees' busy time are handled
+	ConflictPolicy string
 )
 
-func LoadTemplate(file string) (*Template, error) {
-	f, err := os.Open(file)
+// LoadTemplate reads and validates the template file,
+// the defaults are set for the keys missing in the template
+func LoadTemplate(file string, defaults Defaults) (*Template, error) {
+	tree, err := LoadTemplateTree(file)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
+
+	// the key, value and roster problems are collected,
+	// so that a single run reports all of them
+	errs := make([]string, 0)
+	addErr := func(err error) {
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	refs, err := extractRosterRefs(tree)
+	addErr(err)
+	addErr(validateKeys(tree))
 
 	var cfg Template
-	if err := toml.NewDecoder(f).Decode(&cfg); err != nil {
-		return nil, err
+	errs = append(errs, decodeTemplate(tree, &cfg)...)
+
+	addErr(cfg.resolveRoster(refs, filepath.Dir(file)))
+
+	if cfg.Name == "" {
+		cfg.Name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
 	}
 
+	cfg.applyDefaults(defaults)
 	cfg.applyDescriptions()
+	cfg.Recurrence.allDay = cfg.AllDay
+
+	warning, err := cfg.Recurrence.migrateFrequency()
+	addErr(err)
+
+	if warning != "" {
+		cfg.Warnings = append(cfg.Warnings, warning)
+	}
+
+	addErr(cfg.validate())
+	addErr(cfg.Recurrence.loadHolidays(filepath.Dir(file)))
+
+	if len(errs) > 0 {
+		return &cfg, errors.New(strings.Join(errs, "\n"))
+	}
 
-	return &cfg, cfg.validate()
+	return &cfg, nil
 }
 
 func (t *Template) validate() error {
 	validators := []func() error{
 		t.Recurrence.validate,
+		t.validateTimezone,
+		t.validateDuration,
 		t.validateTransparency,
 		t.validateVisibility,
+		t.validateConference,
+		t.validateColors,
+		t.validateAttachments,
+		t.validateRotation,
+		t.validateOnConflict,
+		t.validateParticipants,
+		t.validateStaggered,
+		t.validateTemplates,
+		t.validateReminders,
 	}
 
 	errs := make([]string, 0)
@@ -107,6 +262,76 @@ func (t *Template) validateVisibility() error {
 	return nil
 }
 
+func (t *Template) validateConference() error {
+	validValues := map[string]bool{
+		ConferenceMeet: true,
+		"":             true,
+	}
+
+	if _, ok := validValues[t.Conference]; !ok {
+		return fmt.Errorf("invalid config `conference` value: %s", t.Conference)
+	}
+
+	return nil
+}
+
+func (t *Template) validateColors() error {
+	errs := make([]string, 0)
+
+	if !validColorID(t.ColorID) {
+		errs = append(errs, fmt.Sprintf("invalid config `color_id` value: %s, valid values: \"1\"..\"%d\"", t.ColorID, maxColorID))
+	}
+
+	for _, participant := range t.Participants {
+		if !validColorID(participant.ColorID) {
+			errs = append(errs, fmt.Sprintf("invalid config `color_id` value for %s: %s, valid values: \"1\"..\"%d\"", participant.Email, participant.ColorID, maxColorID))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+
+	return nil
+}
+
+// validColorID checks the google calendar event color id, empty keeps the calendar color
+func validColorID(colorID string) bool {
+	if colorID == "" {
+		return true
+	}
+
+	id, err := strconv.Atoi(colorID)
+	return err == nil && id >= 1 && id <= maxColorID
+}
+
+func (t *Template) validateAttachments() error {
+	if len(t.Attachments) > maxAttachments {
+		return fmt.Errorf("invalid config `attachments`: at most %d attachments are supported", maxAttachments)
+	}
+
+	for _, attachment := range t.Attachments {
+		u, err := url.Parse(attachment)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("invalid config `attachments` value: %s, an https url is expected", attachment)
+		}
+	}
+
+	return nil
+}
+
+// EventColor returns the color of an event assigned to the assignees:
+// the first assignee color or the template one
+func (t *Template) EventColor(assignees ...*Assignee) string {
+	for _, assignee := range assignees {
+		if assignee.ColorID != "" {
+			return assignee.ColorID
+		}
+	}
+
+	return t.ColorID
+}
+
 func (t *Template) validateTransparency() error {
 	validValueMapping := map[string]string{
 		"busy": "opaque",
@@ -125,6 +350,169 @@ func (t *Template) validateTransparency() error {
 	return nil
 }
 
+func (t *Template) validateRotation() error {
+	switch t.Rotation {
+	case "":
+		// overwrite (mutate) config values
+		t.Rotation = RotationManual
+	case RotationManual, RotationRoundRobin, RotationFair:
+	default:
+		return fmt.Errorf("invalid config `rotation` value: %s", t.Rotation)
+	}
+
+	if t.GroupSize == 0 {
+		t.GroupSize = 1
+	}
+
+	if t.GroupSize < 0 || t.GroupSize > len(t.Participants) {
+		return fmt.Errorf("invalid config `group_size` value: %d, must be in range [1..%d]", t.GroupSize, len(t.Participants))
+	}
+
+	if t.HistoryWindow < 0 {
+		return fmt.Errorf("invalid config `history_window` value: %v", t.HistoryWindow)
+	}
+
+	if t.HistoryWindow == 0 {
+		t.HistoryWindow = defaultHistoryWindow
+	}
+
+	return nil
+}
+
+func (t *Template) validateOnConflict() error {
+	switch t.OnConflict {
+	case "":
+		// overwrite (mutate) config values
+		t.OnConflict = ConflictWarn
+	case ConflictWarn, ConflictSkip, ConflictReassign:
+	default:
+		return fmt.Errorf("invalid config `on_conflict` value: %s", t.OnConflict)
+	}
+
+	return nil
+}
+
+func (t *Template) validateTimezone() error {
+	if _, err := ResolveTimezone(t.Timezone); err != nil {
+		return fmt.Errorf("invalid config `timezone` value: %s: %s", t.Timezone, err)
+	}
+
+	return nil
+}
+
+func (t *Template) validateDuration() error {
+	if t.Duration <= 0 {
+		return fmt.Errorf("invalid config `duration` value: %s, must be positive", t.Duration)
+	}
+
+	if t.AllDay && t.Duration%day != 0 {
+		return fmt.Errorf("invalid config `duration` value: %s, all-day events last whole days: \"24h\", \"168h\"", t.Duration)
+	}
+
+	if t.AllDay && (t.Recurrence.Freq == FreqMinutely || t.Recurrence.Freq == FreqHourly) {
+		return fmt.Errorf("invalid config `recurrence.freq` value: %s, all-day events repeat at least daily", t.Recurrence.Freq)
+	}
+
+	return nil
+}
+
+func (t *Template) validateParticipants() error {
+	errs := make([]string, 0)
+
+	if t.EventHost.Email != "" {
+		if err := ValidateEmail(t.EventHost.Email); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid config `host.email` value: %s", err))
+		}
+	}
+
+	for i, attendee := range t.Attendees {
+		if err := ValidateEmail(attendee.Email); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid config `attendees[%d].email` value: %s", i, err))
+		}
+	}
+
+	for i, room := range t.Rooms {
+		if err := ValidateEmail(room); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid config `rooms[%d]` value: %s", i, err))
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, participant := range t.Participants {
+		if err := ValidateEmail(participant.Email); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid config `participants[%d].email` value: %s", i, err))
+		}
+
+		email := strings.ToLower(participant.Email)
+		if seen[email] {
+			errs = append(errs, fmt.Sprintf("invalid config `participants[%d]`: duplicate participant %s", i, participant.Email))
+		}
+		seen[email] = true
+
+		for _, unavailable := range participant.Unavailable {
+			if err := unavailable.validate(); err != nil {
+				errs = append(errs, fmt.Sprintf("invalid config `unavailable` value for %s: %s", participant.Email, err))
+			}
+		}
+	}
+
+	// static attendees are invited in addition to the host and the participants
+	if t.EventHost.Email != "" {
+		seen[strings.ToLower(t.EventHost.Email)] = true
+	}
+
+	for i, attendee := range t.Attendees {
+		email := strings.ToLower(attendee.Email)
+		if seen[email] {
+			errs = append(errs, fmt.Sprintf("invalid config `attendees[%d]`: %s is already invited as the host, a participant or an attendee", i, attendee.Email))
+		}
+		seen[email] = true
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+
+	return nil
+}
+
+func ValidateEmail(email string) error {
+	if email == "" {
+		return errors.New("email is required")
+	}
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return fmt.Errorf("%s is not a valid email address", email)
+	}
+
+	return nil
+}
+
+func (t *Template) validateStaggered() error {
+	if !t.Recurrence.Mode.IsStaggered() {
+		return nil
+	}
+
+	r := &t.Recurrence
+	if len(r.ByDay) > 0 || len(r.ByMonthDay) > 0 || len(r.BySetPos) > 0 || len(r.RDates) > 0 {
+		return errors.New("invalid config `recurrence`: `by_day`, `by_month_day`, `by_set_pos` and `rdates` are not supported with `mode = \"staggered\"`")
+	}
+
+	if t.GroupSize > 0 && len(t.Participants)%t.GroupSize != 0 {
+		return fmt.Errorf("invalid config `group_size` value: %d, staggered series require the participants count to be divisible by it", t.GroupSize)
+	}
+
+	// each series repeats for a single group, it can not skip the unavailable dates
+	for _, participant := range t.Participants {
+		if len(participant.Unavailable) > 0 {
+			return fmt.Errorf("invalid config `unavailable` value for %s: unavailability windows are not supported with `mode = \"staggered\"`", participant.Email)
+		}
+	}
+
+	return nil
+}
+
 func (t *Template) GenerateEventTitle(participants ...*Assignee) string {
 	if !t.TitleWithParticipants {
 		return t.EventTitle
@@ -138,6 +526,22 @@ func (t *Template) GenerateEventTitle(participants ...*Assignee) string {
 	return fmt.Sprintf("%s: %s", t.EventTitle, strings.Join(names, " / "))
 }
 
+// applyDefaults sets the user defaults for the calendar and timezone missing in the template
+func (t *Template) applyDefaults(defaults Defaults) {
+	if t.CalID == "" {
+		t.CalID = defaults.CalID
+	}
+
+	if t.Timezone == "" {
+		t.Timezone = defaults.Timezone
+	}
+}
+
+// Days returns the number of days spanned by the all-day events
+func (t *Template) Days() int {
+	return int(t.Duration / day)
+}
+
 func (t *Template) applyDescriptions() {
 	for _, participant := range t.Participants {
 		if participant.Description == "" {
@@ -148,52 +552,222 @@ func (t *Template) applyDescriptions() {
 
 func (r RecMode) IsSingle() bool    { return r == RecModeSingle }
 func (r RecMode) IsRecurrent() bool { return r == RecModeRecurrent }
+func (r RecMode) IsStaggered() bool { return r == RecModeStaggered }
+
+// IsSeries checks whether the events are created as recurring series
+func (r RecMode) IsSeries() bool { return r.IsRecurrent() || r.IsStaggered() }
+
+func (r Rotation) IsManual() bool     { return r == RotationManual }
+func (r Rotation) IsRoundRobin() bool { return r == RotationRoundRobin }
+func (r Rotation) IsFair() bool       { return r == RotationFair }
+
+func (c ConflictPolicy) IsWarn() bool     { return c == ConflictWarn }
+func (c ConflictPolicy) IsSkip() bool     { return c == ConflictSkip }
+func (c ConflictPolicy) IsReassign() bool { return c == ConflictReassign }
 
 func (r *Recurrence) validate() error {
-	if r.Mode.IsSingle() || r.Mode.IsRecurrent() {
-		return nil
+	switch r.Freq {
+	case FreqMinutely, FreqHourly, FreqDaily, FreqWeekly, FreqMonthly, FreqYearly:
+	case "":
+		if r.Mode.IsSeries() || r.Count > 1 {
+			return errors.New("invalid config `recurrence.freq`: value is required")
+		}
+	default:
+		return fmt.Errorf("invalid config `recurrence.freq` value: %s", r.Freq)
+	}
+
+	for _, holiday := range r.Holidays {
+		if _, err := time.Parse(dateFormat, holiday); err != nil {
+			return fmt.Errorf("invalid config `recurrence.holidays` value: %s", holiday)
+		}
 	}
 
-	if _, err := r.frequency(); err != nil {
+	weekend := true
+	for _, weekday := range r.Weekdays {
+		wd, ok := parseWeekday(weekday)
+		if !ok {
+			return fmt.Errorf("invalid config `recurrence.weekdays` value: %s", weekday)
+		}
+		weekend = weekend && (wd == time.Saturday || wd == time.Sunday)
+	}
+
+	if r.SkipWeekends && len(r.Weekdays) > 0 && weekend {
+		return errors.New("invalid config `recurrence.weekdays`: only weekend days are listed while `skip_weekends` is set")
+	}
+
+	// the filters apply to the planned dates, a series repeats on its rule dates
+	if r.Mode.IsSeries() && r.hasDateFilters() {
+		return errors.New("invalid config `recurrence`: `skip_weekends`, `weekdays`, `holidays` and `holidays_file` require `mode = \"single\"`, use `by_day` and `exdates` to shape a series")
+	}
+
+	if err := r.validateRule(); err != nil {
 		return err
 	}
 
+	if r.Mode.IsSingle() || r.Mode.IsSeries() {
+		return nil
+	}
+
 	return fmt.Errorf("unsupported recurrence mode: %s", r.Mode)
 }
 
-func (r *Recurrence) RFC5545() ([]string, error) {
-	f, err := r.frequency()
-	if err != nil {
-		return nil, err
+func (r *Recurrence) hasDateFilters() bool {
+	return r.SkipWeekends || len(r.Weekdays) > 0 || len(r.Holidays) > 0 || r.HolidaysFile != ""
+}
+
+// IsHoliday checks whether the date is listed in the recurrence holidays
+func (r *Recurrence) IsHoliday(date time.Time) bool {
+	_, ok := r.holiday(date)
+	return ok
+}
+
+// DateFilter returns the filter skipping weekends,
+// not listed weekdays and holidays
+func (r *Recurrence) DateFilter() *planweek.Filter {
+	weekdays := make([]time.Weekday, 0, len(r.Weekdays))
+	for _, weekday := range r.Weekdays {
+		wd, _ := parseWeekday(weekday)
+		weekdays = append(weekdays, wd)
 	}
 
-	var rule string
-	if r.Count < 0 {
-		rule = fmt.Sprintf("RRULE:FREQ=%s;INTERVAL=%d", f, r.Interval)
-	} else {
-		rule = fmt.Sprintf("RRULE:FREQ=%s;COUNT=%d;INTERVAL=%d", f, r.Count, r.Interval)
+	return &planweek.Filter{
+		SkipWeekends: r.SkipWeekends,
+		Weekdays:     weekdays,
+		Holiday:      r.holiday,
 	}
+}
 
-	return []string{rule}, nil
+func (r *Recurrence) holiday(date time.Time) (string, bool) {
+	if r.holidays == nil {
+		for _, holiday := range r.Holidays {
+			if holiday == date.Format(dateFormat) {
+				return "", true
+			}
+		}
+		return "", false
+	}
+
+	return r.holidays.Lookup(date)
 }
 
-func (r *Recurrence) frequency() (string, error) {
-	switch {
-	case r.Frequency.Minutes() <= 1:
-		return "MINUTELY", nil
-	case r.Frequency.Hours() <= 1:
-		return "HOURLY", nil
-	case r.Frequency.Hours() <= 24:
-		return "DAILY", nil
-	case r.Frequency.Hours() <= 24*7:
-		return "WEEKLY", nil
-	case r.Frequency.Hours() <= 24*30:
-		return "MONTHLY", nil
-	case r.Frequency.Hours() <= 24*365:
-		return "YEARLY", nil
+// loadHolidays merges the `holidays` dates with the `holidays_file` entries.
+// A relative file path is resolved against the template directory
+func (r *Recurrence) loadHolidays(dir string) error {
+	r.holidays = make(holidays.Holidays)
+
+	if r.HolidaysFile != "" {
+		file := r.HolidaysFile
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+
+		fromFile, err := holidays.Load(file)
+		if err != nil {
+			return err
+		}
+
+		for date, name := range fromFile {
+			r.holidays[date] = name
+		}
+	}
+
+	for _, holiday := range r.Holidays {
+		date, _ := time.Parse(dateFormat, holiday)
+		if _, ok := r.holidays.Lookup(date); !ok {
+			r.holidays.Add(date, holiday)
+		}
 	}
 
-	return "", fmt.Errorf("frequence is out of range: %v", r.Frequency)
+	return nil
 }
 
 func (a *Assignee) FullName() string { return a.FirstName + " " + a.LastName }
+
+// IsAvailable checks whether the shift starting at the date is outside of
+// the assignee's unavailability windows: every day of [date, date+duration) is checked
+func (a *Assignee) IsAvailable(date time.Time, duration time.Duration) bool {
+	end := date.Add(duration)
+
+	for day := date; ; {
+		for _, unavailable := range a.Unavailable {
+			if unavailable.covers(day) {
+				return false
+			}
+		}
+
+		y, m, d := day.Date()
+		day = time.Date(y, m, d+1, 0, 0, 0, 0, day.Location())
+		if !day.Before(end) {
+			return true
+		}
+	}
+}
+
+func (u *Unavailability) validate() error {
+	if u.From == "" && u.To == "" && len(u.Weekdays) == 0 {
+		return errors.New("empty entry: `from`, `to` or `weekdays` expected")
+	}
+
+	from, err := parseOptionalDate(u.From)
+	if err != nil {
+		return fmt.Errorf("`from`: %s", err)
+	}
+
+	to, err := parseOptionalDate(u.To)
+	if err != nil {
+		return fmt.Errorf("`to`: %s", err)
+	}
+
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return fmt.Errorf("`to` %s is before `from` %s", u.To, u.From)
+	}
+
+	for _, weekday := range u.Weekdays {
+		if _, ok := parseWeekday(weekday); !ok {
+			return fmt.Errorf("unknown weekday: %s", weekday)
+		}
+	}
+
+	return nil
+}
+
+func (u *Unavailability) covers(date time.Time) bool {
+	day := date.Format(dateFormat)
+	if u.From != "" && day < u.From {
+		return false
+	}
+
+	if u.To != "" && day > u.To {
+		return false
+	}
+
+	if len(u.Weekdays) == 0 {
+		return true
+	}
+
+	for _, weekday := range u.Weekdays {
+		if wd, _ := parseWeekday(weekday); wd == date.Weekday() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseOptionalDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(dateFormat, value)
+}
+
+// parseWeekday parses short or full weekday names: "Mon", "monday"
+func parseWeekday(name string) (time.Weekday, bool) {
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		full := wd.String()
+		if strings.EqualFold(name, full) || strings.EqualFold(name, full[:3]) {
+			return wd, true
+		}
+	}
+	return 0, false
+}
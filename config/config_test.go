@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadTemplateReportsAllErrors(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "oncall.toml")
+	content := `cal_id = "c"
+event_title = "On-call"
+duration = "8h"
+colour = "1"
+transparency = "nope"
+participants = [
+  { first_name = "A", email = "a@example.com" },
+  { first_name = "B", email = "not-an-email" },
+]
+
+[host]
+email = "host@example.com"
+
+[recurrence]
+mode = "recurrent"
+count = 6
+freq = "weekly"
+interval = -1
+`
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadTemplate(file, Defaults{})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	want := []string{
+		"unknown config key `colour` at line 4",
+		"invalid config `recurrence.interval` value at line 18",
+		"invalid config `transparency` value: nope",
+		"invalid config `participants[1].email` value: not-an-email",
+	}
+
+	for _, w := range want {
+		if !strings.Contains(err.Error(), w) {
+			t.Errorf("error %q does not contain %q", err, w)
+		}
+	}
+}
+
+func TestIsAvailable(t *testing.T) {
+	assignee := &Assignee{Unavailable: []*Unavailability{
+		{From: "2026-12-24", To: "2026-12-26"},
+		{Weekdays: []string{"Sat"}},
+	}}
+
+	tests := []struct {
+		name     string
+		date     time.Time
+		duration time.Duration
+		want     bool
+	}{
+		{"day before the range", time.Date(2026, 12, 23, 9, 0, 0, 0, time.UTC), 8 * time.Hour, true},
+		{"overnight shift into the range", time.Date(2026, 12, 23, 20, 0, 0, 0, time.UTC), 12 * time.Hour, false},
+		{"shift ending at midnight", time.Date(2026, 12, 23, 16, 0, 0, 0, time.UTC), 8 * time.Hour, true},
+		{"week spanning the unavailable weekday", time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC), 7 * day, false},
+		{"working days", time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC), 4 * day, true},
+		{"start in the range", time.Date(2026, 12, 26, 9, 0, 0, 0, time.UTC), time.Hour, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := assignee.IsAvailable(tt.date, tt.duration); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTemplateDefaults(t *testing.T) {
+	dir := t.TempDir()
+	content := `event_title = "On-call"
+duration = "8h"
+participants = [{ first_name = "A", email = "a@example.com" }]
+
+[host]
+email = "host@example.com"
+
+[recurrence]
+mode = "single"
+count = 1
+`
+
+	tests := []struct {
+		name         string
+		extra        string
+		defaults     Defaults
+		wantCalID    string
+		wantTimezone string
+	}{
+		{"no defaults", "", Defaults{}, "", ""},
+		{"defaults for the missing keys", "", Defaults{CalID: "me@example.com", Timezone: "Europe/Berlin"}, "me@example.com", "Europe/Berlin"},
+		{"template keys win", "cal_id = \"team@example.com\"\ntimezone = \"UTC\"\n", Defaults{CalID: "me@example.com", Timezone: "Europe/Berlin"}, "team@example.com", "UTC"},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(dir, fmt.Sprintf("t%d.toml", i))
+			if err := ioutil.WriteFile(file, []byte(tt.extra+content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			template, err := LoadTemplate(file, tt.defaults)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if template.CalID != tt.wantCalID || template.Timezone != tt.wantTimezone {
+				t.Errorf("got %q %q, want %q %q", template.CalID, template.Timezone, tt.wantCalID, tt.wantTimezone)
+			}
+		})
+	}
+}
+
+func TestValidateParticipantsAttendees(t *testing.T) {
+	tests := []struct {
+		name      string
+		attendees []*Attendee
+		wantErr   bool
+	}{
+		{"observer", []*Attendee{{Email: "observer@example.com"}}, false},
+		{"participant", []*Attendee{{Email: "Alice@example.com"}}, true},
+		{"host", []*Attendee{{Email: "host@example.com"}}, true},
+		{"duplicate attendee", []*Attendee{{Email: "observer@example.com"}, {Email: "observer@example.com"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := &Template{
+				EventHost:    Assignee{Email: "host@example.com"},
+				Participants: []*Assignee{{Email: "alice@example.com"}},
+				Attendees:    tt.attendees,
+			}
+
+			if err := template.validateParticipants(); (err != nil) != tt.wantErr {
+				t.Errorf("got %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
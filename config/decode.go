@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// tomlPositionRe matches the position prefix of the go-toml errors,
+// the position of a value decoded on its own is not the file position
+var tomlPositionRe = regexp.MustCompile(`^\(\d+, \d+\): `)
+
+// decodeTemplate unmarshals the template tree. A type error stops go-toml
+// at the first invalid value, so the keys are then decoded one by one:
+// every invalid value is reported and the valid ones are kept for the validators
+func decodeTemplate(tree *toml.Tree, cfg *Template) []string {
+	if err := tree.Unmarshal(cfg); err == nil {
+		return nil
+	}
+
+	*cfg = Template{}
+	return decodeKeys(tree, reflect.ValueOf(cfg).Elem(), "")
+}
+
+func decodeKeys(tree *toml.Tree, target reflect.Value, prefix string) []string {
+	errs := make([]string, 0)
+
+	for _, key := range tree.Keys() {
+		i, ok := fieldIndex(target.Type(), key)
+		if !ok {
+			// unknown keys are reported by validateKeys
+			continue
+		}
+
+		field := target.Field(i)
+		value := tree.Get(key)
+
+		if sub, ok := value.(*toml.Tree); ok && field.Kind() == reflect.Struct {
+			errs = append(errs, decodeKeys(sub, field, prefix+key+".")...)
+			continue
+		}
+
+		single, err := toml.TreeFromMap(map[string]interface{}{})
+		if err != nil {
+			return append(errs, err.Error())
+		}
+
+		single.SetPath([]string{key}, value)
+
+		part := reflect.New(target.Type())
+		if err := single.Unmarshal(part.Interface()); err != nil {
+			msg := tomlPositionRe.ReplaceAllString(err.Error(), "")
+			errs = append(errs, fmt.Sprintf("invalid config `%s%s` value%s: %s", prefix, key, position(tree, key), msg))
+			continue
+		}
+
+		field.Set(part.Elem().Field(i))
+	}
+
+	return errs
+}
+
+// fieldIndex looks up the struct field by its `toml` tag
+func fieldIndex(typ reflect.Type, key string) (int, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("toml"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+
+		if name == key {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
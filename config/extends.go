@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// pathKeys are the template keys holding file paths
+// relative to the directory of the template declaring them
+var pathKeys = [][]string{
+	{"extends"},
+	{"roster"},
+	{"recurrence", "holidays_file"},
+}
+
+// ownKeys are the template keys which are not inherited: the name
+// keys the rotation cursor and the event history of the template
+var ownKeys = []string{"name", "extends"}
+
+// LoadTemplateTree reads the template file merged over the chain
+// of templates it `extends`. Keys of the extending template override
+// the inherited ones, tables are merged key by key
+func LoadTemplateTree(file string) (*toml.Tree, error) {
+	return loadTemplateTree(file, nil)
+}
+
+func loadTemplateTree(file string, chain []string) (*toml.Tree, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, visited := range chain {
+		if visited == abs {
+			return nil, fmt.Errorf("template inheritance cycle: %s", strings.Join(append(chain, abs), " -> "))
+		}
+	}
+
+	tree, err := loadTree(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := absolutizePaths(tree, filepath.Dir(abs)); err != nil {
+		return nil, fmt.Errorf("%s: %s", abs, err)
+	}
+
+	if !tree.Has("extends") {
+		return tree, nil
+	}
+
+	parent, ok := tree.Get("extends").(string)
+	if !ok {
+		return nil, fmt.Errorf("%s: invalid config `extends` value: %v", abs, tree.Get("extends"))
+	}
+
+	if err := tree.Delete("extends"); err != nil {
+		return nil, err
+	}
+
+	base, err := loadTemplateTree(parent, append(chain, abs))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range ownKeys {
+		if !base.Has(key) {
+			continue
+		}
+		if err := base.Delete(key); err != nil {
+			return nil, err
+		}
+	}
+
+	mergeTree(base, tree)
+	return base, nil
+}
+
+// mergeTree copies the `src` keys into `dst`, merging nested tables
+func mergeTree(dst, src *toml.Tree) {
+	for _, key := range src.Keys() {
+		path := []string{key}
+		srcValue := src.GetPath(path)
+
+		srcTree, srcIsTree := srcValue.(*toml.Tree)
+		dstTree, dstIsTree := dst.GetPath(path).(*toml.Tree)
+		if srcIsTree && dstIsTree {
+			mergeTree(dstTree, srcTree)
+			continue
+		}
+
+		dst.SetPath(path, srcValue)
+	}
+}
+
+func absolutizePaths(tree *toml.Tree, dir string) error {
+	for _, path := range pathKeys {
+		if !tree.HasPath(path) {
+			continue
+		}
+
+		value, ok := tree.GetPath(path).(string)
+		if !ok {
+			return fmt.Errorf("invalid config `%s` value: %v", strings.Join(path, "."), tree.GetPath(path))
+		}
+
+		if value != "" && !filepath.IsAbs(value) {
+			tree.SetPath(path, filepath.Join(dir, value))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadTemplateExtends(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"base.toml": `name = "base-rotation"
+cal_id = "team@example.com"
+event_title = "On-call"
+duration = "8h"
+timezone = "UTC"
+
+[host]
+email = "host@example.com"
+
+[recurrence]
+mode = "single"
+count = 2
+freq = "weekly"
+interval = 1
+`,
+		"sub/backend.toml": `extends = "../base.toml"
+participants = [
+  { first_name = "Alice", email = "alice@example.com" },
+]
+
+[recurrence]
+count = 4
+`,
+	}
+
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	template, err := LoadTemplate(filepath.Join(dir, "sub", "backend.toml"), Defaults{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"name defaults to the file name", template.Name, "backend"},
+		{"inherited key", template.CalID, "team@example.com"},
+		{"inherited table key", string(template.Recurrence.Freq), "weekly"},
+		{"overridden table key", template.Recurrence.Count, int32(4)},
+		{"own participants", len(template.Participants), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+	"gopkg.in/yaml.v2"
+)
+
+// TemplateExtensions are the supported template file formats
+var TemplateExtensions = []string{".toml", ".yaml", ".yml", ".json"}
+
+// IsTemplateFile reports whether the file format is supported
+func IsTemplateFile(file string) bool {
+	ext := strings.ToLower(filepath.Ext(file))
+	for _, supported := range TemplateExtensions {
+		if ext == supported {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadTree decodes a TOML, YAML or JSON file depending on its extension
+func loadTree(file string) (*toml.Tree, error) {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		return loadTreeWith(file, decodeYAML)
+	case ".json":
+		return loadTreeWith(file, decodeJSON)
+	default:
+		return toml.LoadFile(file)
+	}
+}
+
+func loadTreeWith(file string, decode func([]byte) (map[string]interface{}, error)) (*toml.Tree, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := decode(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+
+	return toml.TreeFromMap(m)
+}
+
+func decodeYAML(b []byte) (map[string]interface{}, error) {
+	var raw map[interface{}]interface{}
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+
+	m, err := normalize(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if m == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	return m.(map[string]interface{}), nil
+}
+
+func decodeJSON(b []byte) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var raw map[string]interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	m, err := normalize(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if m == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	return m.(map[string]interface{}), nil
+}
+
+// normalize converts the decoded YAML and JSON values
+// to the types understood by the TOML tree
+func normalize(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			k, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid key `%v`: keys must be strings", key)
+			}
+
+			n, err := normalize(item)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = n
+		}
+		return m, nil
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			n, err := normalize(item)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = n
+		}
+		return m, nil
+	case []interface{}:
+		s := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			n, err := normalize(item)
+			if err != nil {
+				return nil, err
+			}
+			s = append(s, n)
+		}
+		return s, nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	case int:
+		return int64(v), nil
+	default:
+		return v, nil
+	}
+}
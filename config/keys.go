@@ -0,0 +1,112 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// validateKeys reports the template keys not matching any template field,
+// typos would otherwise be silently ignored
+func validateKeys(tree *toml.Tree) error {
+	unknown := unknownKeys(tree, reflect.TypeOf(Template{}), "")
+	if len(unknown) > 0 {
+		return errors.New(strings.Join(unknown, "\n"))
+	}
+
+	return nil
+}
+
+func unknownKeys(tree *toml.Tree, typ reflect.Type, prefix string) []string {
+	fields := tomlFields(typ)
+	unknown := make([]string, 0)
+
+	for _, key := range tree.Keys() {
+		path := prefix + key
+
+		field, ok := fields[key]
+		if !ok {
+			unknown = append(unknown, fmt.Sprintf("unknown config key `%s`%s", path, position(tree, key)))
+			continue
+		}
+
+		for i, sub := range subtrees(tree.Get(key)) {
+			subPath := path
+			if _, isTree := tree.Get(key).(*toml.Tree); !isTree {
+				subPath = fmt.Sprintf("%s[%d]", path, i)
+			}
+			unknown = append(unknown, unknownKeys(sub, field, subPath+".")...)
+		}
+	}
+
+	return unknown
+}
+
+// tomlFields maps the `toml` tags of a struct to the field types,
+// dereferencing pointers and slice elements
+func tomlFields(typ reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+
+	typ = elemType(typ)
+	if typ.Kind() != reflect.Struct {
+		return fields
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("toml"), ",")[0]
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+
+		fields[name] = field.Type
+	}
+
+	return fields
+}
+
+func elemType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+// subtrees returns the tables of a table or array of tables value
+func subtrees(value interface{}) []*toml.Tree {
+	switch v := value.(type) {
+	case *toml.Tree:
+		return []*toml.Tree{v}
+	case []*toml.Tree:
+		return v
+	case []interface{}:
+		trees := make([]*toml.Tree, 0, len(v))
+		for _, item := range v {
+			if sub, ok := item.(*toml.Tree); ok {
+				trees = append(trees, sub)
+			}
+		}
+		return trees
+	}
+
+	return nil
+}
+
+func position(tree *toml.Tree, key string) string {
+	pos := tree.GetPosition(key)
+	if pos.Invalid() {
+		return ""
+	}
+
+	return fmt.Sprintf(" at line %d", pos.Line)
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	ReminderPopup = "popup"
+	ReminderEmail = "email"
+
+	// maxReminders and maxReminderMinutes are the google calendar limits
+	maxReminders       = 5
+	maxReminderMinutes = 40320
+)
+
+// Reminder describes an event notification sent `minutes` before the event start.
+// A `use_default` reminder keeps the default reminders of the calendar
+type Reminder struct {
+	Method     string `toml:"method"`
+	Minutes    int64  `toml:"minutes"`
+	UseDefault bool   `toml:"use_default"`
+}
+
+// EventReminders returns the reminders of an event assigned to the assignees.
+// The assignee reminders override the template ones, nil keeps the calendar defaults.
+// Reminders of a group are merged: overrides take precedence over `use_default`
+// and the first maxReminders are kept, in the order of the assignees
+func (t *Template) EventReminders(assignees ...*Assignee) []*Reminder {
+	reminders := make([]*Reminder, 0)
+	seen := make(map[Reminder]bool)
+
+	for _, assignee := range assignees {
+		for _, reminder := range assignee.Reminders {
+			if !seen[*reminder] {
+				seen[*reminder] = true
+				reminders = append(reminders, reminder)
+			}
+		}
+	}
+
+	if len(reminders) > 1 && UsesDefault(reminders) {
+		overrides := make([]*Reminder, 0, len(reminders))
+		for _, reminder := range reminders {
+			if !reminder.UseDefault {
+				overrides = append(overrides, reminder)
+			}
+		}
+		reminders = overrides
+	}
+
+	if len(reminders) > maxReminders {
+		reminders = reminders[:maxReminders]
+	}
+
+	if len(reminders) > 0 {
+		return reminders
+	}
+
+	if len(t.Reminders) > 0 {
+		return t.Reminders
+	}
+
+	return nil
+}
+
+// UsesDefault checks whether the calendar default reminders are requested
+func UsesDefault(reminders []*Reminder) bool {
+	for _, reminder := range reminders {
+		if reminder.UseDefault {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Template) validateReminders() error {
+	errs := make([]string, 0)
+
+	if err := validateReminders(t.Reminders); err != nil {
+		errs = append(errs, fmt.Sprintf("invalid config `reminders`: %s", err))
+	}
+
+	for _, participant := range t.Participants {
+		if err := validateReminders(participant.Reminders); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid config `reminders` value for %s: %s", participant.Email, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+
+	return nil
+}
+
+func validateReminders(reminders []*Reminder) error {
+	if UsesDefault(reminders) {
+		if len(reminders) > 1 {
+			return errors.New("`use_default` can not be combined with other reminders")
+		}
+		return nil
+	}
+
+	if len(reminders) > maxReminders {
+		return fmt.Errorf("at most %d reminders are supported", maxReminders)
+	}
+
+	for _, reminder := range reminders {
+		switch reminder.Method {
+		case ReminderPopup, ReminderEmail:
+		default:
+			return fmt.Errorf("invalid `method` value: %s, valid values: %s, %s", reminder.Method, ReminderPopup, ReminderEmail)
+		}
+
+		if reminder.Minutes < 0 || reminder.Minutes > maxReminderMinutes {
+			return fmt.Errorf("invalid `minutes` value: %d, valid range: 0..%d", reminder.Minutes, maxReminderMinutes)
+		}
+	}
+
+	return nil
+}
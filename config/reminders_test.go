@@ -0,0 +1,72 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEventReminders(t *testing.T) {
+	popup := func(minutes int64) *Reminder { return &Reminder{Method: ReminderPopup, Minutes: minutes} }
+	useDefault := &Reminder{UseDefault: true}
+
+	template := &Template{Reminders: []*Reminder{popup(15)}}
+
+	tests := []struct {
+		name      string
+		assignees []*Assignee
+		want      []*Reminder
+	}{
+		{
+			name:      "template reminders",
+			assignees: []*Assignee{{}},
+			want:      []*Reminder{popup(15)},
+		},
+		{
+			name:      "assignee override",
+			assignees: []*Assignee{{Reminders: []*Reminder{popup(30)}}},
+			want:      []*Reminder{popup(30)},
+		},
+		{
+			name: "group reminders are merged without duplicates",
+			assignees: []*Assignee{
+				{Reminders: []*Reminder{popup(30), popup(60)}},
+				{Reminders: []*Reminder{popup(60), popup(120)}},
+			},
+			want: []*Reminder{popup(30), popup(60), popup(120)},
+		},
+		{
+			name: "group reminders are limited",
+			assignees: []*Assignee{
+				{Reminders: []*Reminder{popup(1), popup(2), popup(3)}},
+				{Reminders: []*Reminder{popup(4), popup(5), popup(6)}},
+			},
+			want: []*Reminder{popup(1), popup(2), popup(3), popup(4), popup(5)},
+		},
+		{
+			name: "overrides take precedence over the defaults",
+			assignees: []*Assignee{
+				{Reminders: []*Reminder{useDefault}},
+				{Reminders: []*Reminder{popup(30)}},
+			},
+			want: []*Reminder{popup(30)},
+		},
+		{
+			name:      "assignee defaults",
+			assignees: []*Assignee{{Reminders: []*Reminder{useDefault}}},
+			want:      []*Reminder{useDefault},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := template.EventReminders(tt.assignees...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+
+			if len(got) > maxReminders {
+				t.Errorf("got %d reminders, at most %d are supported", len(got), maxReminders)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const templateActionDelim = "{{"
+
+// EventData is available to the `event_title` and `description` go templates:
+//
+//	{{ names .Assignees }} on call, handover from {{ names .Previous }}, next up is {{ names .Next }}
+type EventData struct {
+	Assignees []*Assignee
+	Host      *Assignee
+	Start     time.Time
+	End       time.Time
+	// Index is the position of the shift in the plan, starting from 0
+	Index int
+	// Number is the position of the shift in the plan, starting from 1
+	Number int
+	// Previous and Next are the assignees of the neighbouring shifts, empty at the plan edges
+	Previous []*Assignee
+	Next     []*Assignee
+}
+
+var templateFuncs = template.FuncMap{
+	"names": func(people []*Assignee) string {
+		names := make([]string, 0, len(people))
+		for _, p := range people {
+			names = append(names, p.FullName())
+		}
+		return strings.Join(names, ", ")
+	},
+	"firstNames": func(people []*Assignee) string {
+		names := make([]string, 0, len(people))
+		for _, p := range people {
+			names = append(names, p.FirstName)
+		}
+		return strings.Join(names, " / ")
+	},
+}
+
+// RenderEventTitle renders the `event_title` go template.
+// Plain titles keep the "Title: A / B" format of `title_with_participants`
+func (t *Template) RenderEventTitle(data *EventData) (string, error) {
+	if !strings.Contains(t.EventTitle, templateActionDelim) {
+		return t.GenerateEventTitle(append(data.Assignees, data.Host)...), nil
+	}
+
+	return render("event_title", t.EventTitle, data)
+}
+
+// RenderDescription renders a description go template
+func RenderDescription(description string, data *EventData) (string, error) {
+	if !strings.Contains(description, templateActionDelim) {
+		return description, nil
+	}
+
+	return render("description", description, data)
+}
+
+func (t *Template) validateTemplates() error {
+	texts := map[string]string{
+		"event_title": t.EventTitle,
+		"description": t.Description,
+	}
+
+	for _, participant := range t.Participants {
+		texts[fmt.Sprintf("description of %s", participant.Email)] = participant.Description
+	}
+
+	errs := make([]string, 0)
+	for name, text := range texts {
+		if _, err := template.New(name).Funcs(templateFuncs).Parse(text); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid config `%s` template: %s", name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+
+	return nil
+}
+
+func render(name, text string, data *EventData) (string, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+
+	return out.String(), nil
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+const groupRefPrefix = "@"
+
+type (
+	// Roster is a shared file of named people and groups
+	// referenced by the templates
+	Roster struct {
+		People map[string]*Assignee `toml:"people"`
+		Groups map[string][]string  `toml:"groups"`
+	}
+
+	// rosterRefs holds the template references to the roster:
+	// `participants = ["@group", "name"]` and `host = "name"`
+	rosterRefs struct {
+		participants []string
+		host         string
+	}
+)
+
+// LoadRoster reads a roster file
+func LoadRoster(file string) (*Roster, error) {
+	tree, err := loadTree(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var roster Roster
+	if err := tree.Unmarshal(&roster); err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+
+	for group, members := range roster.Groups {
+		for _, member := range members {
+			if _, ok := roster.People[member]; !ok {
+				return nil, fmt.Errorf("%s: unknown person `%s` in group `%s`", file, member, group)
+			}
+		}
+	}
+
+	return &roster, nil
+}
+
+// extractRosterRefs removes the roster references from the template tree,
+// so that the rest of the template can be decoded
+func extractRosterRefs(tree *toml.Tree) (*rosterRefs, error) {
+	var refs rosterRefs
+
+	if participants, ok := tree.Get("participants").([]interface{}); ok {
+		for _, p := range participants {
+			name, ok := p.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid config `participants` value: %v", p)
+			}
+			refs.participants = append(refs.participants, name)
+		}
+
+		if err := tree.Delete("participants"); err != nil {
+			return nil, err
+		}
+	}
+
+	if host, ok := tree.Get("host").(string); ok {
+		refs.host = host
+		if err := tree.Delete("host"); err != nil {
+			return nil, err
+		}
+	}
+
+	if refs.host == "" && len(refs.participants) == 0 {
+		return nil, nil
+	}
+
+	return &refs, nil
+}
+
+// resolveRoster replaces the roster references with copies of the roster people
+func (t *Template) resolveRoster(refs *rosterRefs, dir string) error {
+	if refs == nil {
+		return nil
+	}
+
+	if t.Roster == "" {
+		return errors.New("invalid config: `participants` or `host` reference people while `roster` is not set")
+	}
+
+	file := t.Roster
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+
+	roster, err := LoadRoster(file)
+	if err != nil {
+		return err
+	}
+
+	errs := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, ref := range refs.participants {
+		names := []string{ref}
+		if strings.HasPrefix(ref, groupRefPrefix) {
+			members, ok := roster.Groups[strings.TrimPrefix(ref, groupRefPrefix)]
+			if !ok {
+				errs = append(errs, fmt.Sprintf("invalid config `participants`: unknown roster group `%s`", ref))
+				continue
+			}
+			names = members
+		}
+
+		for _, name := range names {
+			person, ok := roster.People[name]
+			if !ok {
+				errs = append(errs, fmt.Sprintf("invalid config `participants`: unknown roster person `%s`", name))
+				continue
+			}
+
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			participant := *person
+			t.Participants = append(t.Participants, &participant)
+		}
+	}
+
+	if refs.host != "" {
+		host, ok := roster.People[refs.host]
+		if ok {
+			t.EventHost = *host
+		} else {
+			errs = append(errs, fmt.Sprintf("invalid config `host`: unknown roster person `%s`", refs.host))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+
+	return nil
+}
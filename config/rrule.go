@@ -0,0 +1,365 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/makarski/gcaler/planweek"
+)
+
+const (
+	rfc5545DateTimeFormat = "20060102T150405Z"
+	rfc5545DateFormat     = "20060102"
+
+	freqWeekly  = "WEEKLY"
+	freqMonthly = "MONTHLY"
+	freqYearly  = "YEARLY"
+)
+
+var (
+	byDayRe = regexp.MustCompile(`^([+-]?\d{1,2})?(MO|TU|WE|TH|FR|SA|SU)$`)
+
+	rfc5545Weekdays = map[string]bool{
+		"MO": true, "TU": true, "WE": true, "TH": true, "FR": true, "SA": true, "SU": true,
+	}
+)
+
+// RFC5545 returns the RRULE, EXDATE and RDATE lines of the recurrence.
+// Dates are anchored to the wall clock time of the event start
+func (r *Recurrence) RFC5545(start time.Time) ([]string, error) {
+	f, err := r.frequency()
+	if err != nil {
+		return nil, err
+	}
+
+	parts := []string{"FREQ=" + f}
+
+	switch {
+	case r.Until != "":
+		until, err := r.until(start.Location())
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, "UNTIL="+r.formatDate(until, start.Location()))
+	case r.Count >= 0:
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+
+	if r.Interval > 0 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+
+	if len(r.ByDay) > 0 {
+		parts = append(parts, "BYDAY="+strings.ToUpper(strings.Join(r.ByDay, ",")))
+	}
+
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+
+	if len(r.BySetPos) > 0 {
+		parts = append(parts, "BYSETPOS="+joinInts(r.BySetPos))
+	}
+
+	if r.Wkst != "" {
+		parts = append(parts, "WKST="+strings.ToUpper(r.Wkst))
+	}
+
+	rules := []string{"RRULE:" + strings.Join(parts, ";")}
+
+	for _, dates := range []struct {
+		name   string
+		values []string
+	}{
+		{"EXDATE", r.ExDates},
+		{"RDATE", r.RDates},
+	} {
+		if len(dates.values) == 0 {
+			continue
+		}
+
+		formatted := make([]string, 0, len(dates.values))
+		for _, value := range dates.values {
+			date, err := parseRuleDate(value, start)
+			if err != nil {
+				return nil, err
+			}
+			formatted = append(formatted, r.formatDate(date, start.Location()))
+		}
+
+		name := dates.name
+		if r.allDay {
+			name += ";VALUE=DATE"
+		}
+
+		rules = append(rules, name+":"+strings.Join(formatted, ","))
+	}
+
+	return rules, nil
+}
+
+// formatDate formats the rule date as UTC date time,
+// or as a date in the event location for the all-day events
+func (r *Recurrence) formatDate(date time.Time, loc *time.Location) string {
+	if r.allDay {
+		return date.In(loc).Format(rfc5545DateFormat)
+	}
+	return date.UTC().Format(rfc5545DateTimeFormat)
+}
+
+// validateRule checks the recurrence rule parts and their combinations
+func (r *Recurrence) validateRule() error {
+	if r.Mode.IsSingle() {
+		if r.hasRuleParts() {
+			return errors.New("invalid config `recurrence`: rule parts (`by_day`, `by_month_day`, `by_set_pos`, `until`, `wkst`, `exdates`, `rdates`) require `mode = \"recurrent\"`")
+		}
+		return nil
+	}
+
+	f, err := r.frequency()
+	if err != nil {
+		return err
+	}
+
+	errs := make([]string, 0)
+	addErr := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf("invalid config `recurrence`: "+format, args...))
+	}
+
+	for _, day := range r.ByDay {
+		m := byDayRe.FindStringSubmatch(strings.ToUpper(day))
+		if m == nil {
+			addErr("`by_day` value: %s", day)
+			continue
+		}
+
+		if m[1] == "" {
+			continue
+		}
+
+		if f != freqMonthly && f != freqYearly {
+			addErr("`by_day` ordinal %s is only allowed with monthly or yearly frequency", day)
+		}
+
+		if n, _ := strconv.Atoi(m[1]); n == 0 || n < -53 || n > 53 {
+			addErr("`by_day` ordinal is out of range: %s", day)
+		}
+	}
+
+	for _, day := range r.ByMonthDay {
+		if day == 0 || day < -31 || day > 31 {
+			addErr("`by_month_day` value is out of range: %d", day)
+		}
+	}
+
+	if len(r.ByMonthDay) > 0 && f == freqWeekly {
+		addErr("`by_month_day` is not allowed with weekly frequency")
+	}
+
+	for _, pos := range r.BySetPos {
+		if pos == 0 || pos < -366 || pos > 366 {
+			addErr("`by_set_pos` value is out of range: %d", pos)
+		}
+	}
+
+	if len(r.BySetPos) > 0 && len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+		addErr("`by_set_pos` requires `by_day` or `by_month_day`")
+	}
+
+	if r.Until != "" {
+		if _, err := r.until(time.UTC); err != nil {
+			addErr("`until` value: %s", r.Until)
+		}
+
+		if r.Count > 0 {
+			addErr("`until` and `count` are mutually exclusive, set `count = -1`")
+		}
+	}
+
+	if r.Wkst != "" && !rfc5545Weekdays[strings.ToUpper(r.Wkst)] {
+		addErr("`wkst` value: %s", r.Wkst)
+	}
+
+	for _, value := range append(append([]string{}, r.ExDates...), r.RDates...) {
+		if _, err := parseRuleDate(value, time.Time{}); err != nil {
+			addErr("`exdates` / `rdates` value: %s", value)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+
+	return nil
+}
+
+func (r *Recurrence) hasRuleParts() bool {
+	return len(r.ByDay) > 0 ||
+		len(r.ByMonthDay) > 0 ||
+		len(r.BySetPos) > 0 ||
+		r.Until != "" ||
+		r.Wkst != "" ||
+		len(r.ExDates) > 0 ||
+		len(r.RDates) > 0
+}
+
+// until returns the inclusive end of the recurrence:
+// the end of the day for dates, the exact time for RFC3339 values
+func (r *Recurrence) until(loc *time.Location) (time.Time, error) {
+	if date, err := time.ParseInLocation(dateFormat, r.Until, loc); err == nil {
+		return date.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+
+	return time.Parse(time.RFC3339, r.Until)
+}
+
+// FrequencyStep returns the step of a single frequency unit
+func (r *Recurrence) FrequencyStep() (planweek.Step, error) {
+	switch r.Freq {
+	case FreqMinutely:
+		return planweek.Step{Fixed: time.Minute}, nil
+	case FreqHourly:
+		return planweek.Step{Fixed: time.Hour}, nil
+	case FreqDaily:
+		return planweek.Step{Days: 1}, nil
+	case FreqWeekly:
+		return planweek.Step{Days: 7}, nil
+	case FreqMonthly:
+		return planweek.Step{Months: 1}, nil
+	case FreqYearly:
+		return planweek.Step{Months: 12}, nil
+	}
+
+	return planweek.Step{}, fmt.Errorf("unsupported recurrence frequency: %s", r.Freq)
+}
+
+// Step returns the distance between two planned events: `interval` frequency units
+func (r *Recurrence) Step() planweek.Step {
+	unit, _ := r.FrequencyStep()
+
+	interval := int(r.Interval)
+	if interval == 0 {
+		interval = 1
+	}
+
+	return planweek.Step{
+		Fixed:  unit.Fixed * time.Duration(interval),
+		Days:   unit.Days * interval,
+		Months: unit.Months * interval,
+	}
+}
+
+func (r *Recurrence) frequency() (string, error) {
+	if _, err := r.FrequencyStep(); err != nil {
+		return "", err
+	}
+
+	return strings.ToUpper(string(r.Freq)), nil
+}
+
+// migrateFrequency converts the deprecated duration based `frequency`
+// to `freq` and `interval`. Single events keep their exact step,
+// recurring series keep the rule they were created with before:
+// the frequency guessed from the duration and the configured interval
+func (r *Recurrence) migrateFrequency() (string, error) {
+	if r.Frequency.IsZero() {
+		return "", nil
+	}
+
+	if r.Freq != "" {
+		return "", errors.New("invalid config `recurrence`: `frequency` is deprecated and can not be combined with `freq`")
+	}
+
+	if r.Frequency.Fixed < 0 || r.Frequency.Days < 0 || r.Frequency.Months < 0 {
+		return "", fmt.Errorf("invalid config `recurrence.frequency` value: %s", r.Frequency)
+	}
+
+	if r.Mode.IsSingle() {
+		freq, interval, err := exactFreq(r.Frequency)
+		if err != nil {
+			return "", err
+		}
+		r.Freq, r.Interval = freq, interval
+	} else {
+		freq, err := legacyFreq(r.Frequency)
+		if err != nil {
+			return "", err
+		}
+		r.Freq = freq
+	}
+
+	return fmt.Sprintf(
+		"`recurrence.frequency = \"%s\"` is deprecated, interpreted as `freq = \"%s\"`, `interval = %d`: update the template",
+		r.Frequency,
+		r.Freq,
+		r.Interval,
+	), nil
+}
+
+// exactFreq converts a step into a frequency unit and an interval
+func exactFreq(step planweek.Step) (Freq, uint32, error) {
+	switch {
+	case step.Fixed == 0 && step.Days == 0 && step.Months%12 == 0:
+		return FreqYearly, uint32(step.Months / 12), nil
+	case step.Fixed == 0 && step.Days == 0:
+		return FreqMonthly, uint32(step.Months), nil
+	case step.Fixed == 0 && step.Months == 0 && step.Days%7 == 0:
+		return FreqWeekly, uint32(step.Days / 7), nil
+	case step.Fixed == 0 && step.Months == 0:
+		return FreqDaily, uint32(step.Days), nil
+	case step.Days == 0 && step.Months == 0 && step.Fixed%time.Hour == 0:
+		return FreqHourly, uint32(step.Fixed / time.Hour), nil
+	case step.Days == 0 && step.Months == 0 && step.Fixed%time.Minute == 0:
+		return FreqMinutely, uint32(step.Fixed / time.Minute), nil
+	}
+
+	return "", 0, fmt.Errorf("invalid config `recurrence.frequency` value: %s can not be converted to `freq`", step)
+}
+
+// legacyFreq guesses the frequency from the duration
+// the way recurring series were created before `freq`
+func legacyFreq(step planweek.Step) (Freq, error) {
+	approx := step.Approx()
+	switch {
+	case approx.Minutes() <= 1:
+		return FreqMinutely, nil
+	case approx.Hours() <= 1:
+		return FreqHourly, nil
+	case approx.Hours() <= 24:
+		return FreqDaily, nil
+	case approx.Hours() <= 24*7:
+		return FreqWeekly, nil
+	case approx.Hours() <= 24*30:
+		return FreqMonthly, nil
+	case approx.Hours() <= 24*365:
+		return FreqYearly, nil
+	}
+
+	return "", fmt.Errorf("frequence is out of range: %v", step)
+}
+
+// parseRuleDate parses RFC3339 date times or dates.
+// Dates get the wall clock time and location of the event start
+func parseRuleDate(value string, start time.Time) (time.Time, error) {
+	if date, err := time.Parse(dateFormat, value); err == nil {
+		return time.Date(
+			date.Year(), date.Month(), date.Day(),
+			start.Hour(), start.Minute(), start.Second(), 0,
+			start.Location(),
+		), nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+func joinInts(values []int) string {
+	strs := make([]string, 0, len(values))
+	for _, v := range values {
+		strs = append(strs, strconv.Itoa(v))
+	}
+	return strings.Join(strs, ",")
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRFC5545DST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the series starts in summer time and runs past the october transition
+	summerStart := time.Date(2026, 10, 19, 9, 0, 0, 0, berlin)
+	// the series starts in winter time and runs past the march transition
+	winterStart := time.Date(2027, 3, 22, 9, 0, 0, 0, berlin)
+
+	tests := []struct {
+		name       string
+		recurrence Recurrence
+		start      time.Time
+		want       []string
+	}{
+		{
+			name:       "until date after the october transition",
+			recurrence: Recurrence{Mode: RecModeRecurrent, Freq: FreqWeekly, Count: -1, Until: "2026-11-02"},
+			start:      summerStart,
+			want:       []string{"RRULE:FREQ=WEEKLY;UNTIL=20261102T225959Z"},
+		},
+		{
+			name:       "exdates across the october transition",
+			recurrence: Recurrence{Mode: RecModeRecurrent, Freq: FreqWeekly, Count: 4, ExDates: []string{"2026-10-19", "2026-10-26"}},
+			start:      summerStart,
+			want:       []string{"RRULE:FREQ=WEEKLY;COUNT=4", "EXDATE:20261019T070000Z,20261026T080000Z"},
+		},
+		{
+			name:       "until date after the march transition",
+			recurrence: Recurrence{Mode: RecModeRecurrent, Freq: FreqWeekly, Count: -1, Until: "2027-04-05"},
+			start:      winterStart,
+			want:       []string{"RRULE:FREQ=WEEKLY;UNTIL=20270405T215959Z"},
+		},
+		{
+			name:       "exdates and rdates across the march transition",
+			recurrence: Recurrence{Mode: RecModeRecurrent, Freq: FreqWeekly, Count: 4, ExDates: []string{"2027-03-22", "2027-03-29"}, RDates: []string{"2027-03-30"}},
+			start:      winterStart,
+			want:       []string{"RRULE:FREQ=WEEKLY;COUNT=4", "EXDATE:20270322T080000Z,20270329T070000Z", "RDATE:20270330T070000Z"},
+		},
+		{
+			name:       "all-day exdates keep the local date",
+			recurrence: Recurrence{Mode: RecModeRecurrent, Freq: FreqWeekly, Count: 4, ExDates: []string{"2026-10-26"}, allDay: true},
+			start:      time.Date(2026, 10, 19, 0, 0, 0, 0, berlin),
+			want:       []string{"RRULE:FREQ=WEEKLY;COUNT=4", "EXDATE;VALUE=DATE:20261026"},
+		},
+		{
+			name:       "all-day until date across the october transition",
+			recurrence: Recurrence{Mode: RecModeRecurrent, Freq: FreqWeekly, Count: -1, Until: "2026-11-02", allDay: true},
+			start:      time.Date(2026, 10, 19, 0, 0, 0, 0, berlin),
+			want:       []string{"RRULE:FREQ=WEEKLY;UNTIL=20261102"},
+		},
+		{
+			name:       "all-day exdates and rdates across the march transition",
+			recurrence: Recurrence{Mode: RecModeRecurrent, Freq: FreqWeekly, Count: 4, ExDates: []string{"2027-03-22", "2027-03-29"}, RDates: []string{"2027-03-30"}, allDay: true},
+			start:      time.Date(2027, 3, 22, 0, 0, 0, 0, berlin),
+			want:       []string{"RRULE:FREQ=WEEKLY;COUNT=4", "EXDATE;VALUE=DATE:20270322,20270329", "RDATE;VALUE=DATE:20270330"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.recurrence.RFC5545(tt.start)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	localTimezone = "Local"
+	zoneinfoDir   = "zoneinfo/"
+)
+
+// ResolveTimezone returns the IANA name of the template timezone.
+// "Local" is resolved to the system zone name, so that events
+// and recurring series are anchored to a real zone across DST
+func ResolveTimezone(name string) (string, error) {
+	switch name {
+	case "", "UTC":
+		return "UTC", nil
+	case localTimezone:
+		return systemTimezone()
+	}
+
+	if _, err := time.LoadLocation(name); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// LoadLocation returns the location of the resolved template timezone
+func LoadLocation(name string) (*time.Location, error) {
+	tzName, err := ResolveTimezone(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return time.LoadLocation(tzName)
+}
+
+// systemTimezone looks up the local zone name in the TZ environment variable,
+// the /etc/localtime symlink target and /etc/timezone
+func systemTimezone() (string, error) {
+	if tz, ok := os.LookupEnv("TZ"); ok {
+		tz = strings.TrimPrefix(tz, ":")
+		if tz == "" {
+			return "UTC", nil
+		}
+
+		if name, ok := zoneFromPath(tz); ok {
+			return name, nil
+		}
+
+		if _, err := time.LoadLocation(tz); err == nil {
+			return tz, nil
+		}
+	}
+
+	if target, err := filepath.EvalSymlinks("/etc/localtime"); err == nil {
+		if name, ok := zoneFromPath(target); ok {
+			return name, nil
+		}
+	}
+
+	if b, err := os.ReadFile("/etc/timezone"); err == nil {
+		name := strings.TrimSpace(string(b))
+		if _, err := time.LoadLocation(name); err == nil {
+			return name, nil
+		}
+	}
+
+	return "", errors.New("unable to resolve the local timezone name, set the template `timezone` explicitly")
+}
+
+// zoneFromPath extracts the zone name from a zoneinfo file path:
+// "/usr/share/zoneinfo/Europe/Berlin" is "Europe/Berlin"
+func zoneFromPath(path string) (string, bool) {
+	i := strings.LastIndex(path, zoneinfoDir)
+	if i < 0 {
+		return "", false
+	}
+
+	name := path[i+len(zoneinfoDir):]
+	if _, err := time.LoadLocation(name); err != nil {
+		return "", false
+	}
+
+	return name, true
+}
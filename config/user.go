@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pelletier/go-toml"
+)
+
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+type (
+	// UserConfig holds the user defaults and the named profiles overriding them
+	UserConfig struct {
+		Profile
+		Profiles map[string]Profile `toml:"profiles"`
+	}
+
+	// Profile describes the user settings, empty values are not set
+	Profile struct {
+		TemplatesDir    string `toml:"templates_dir"`
+		CredentialsFile string `toml:"credentials_file"`
+		Calendar        string `toml:"calendar"`
+		Timezone        string `toml:"timezone"`
+		Output          string `toml:"output"`
+	}
+
+	// Defaults are the user settings used for the keys missing in the templates
+	Defaults struct {
+		CalID    string
+		Timezone string
+	}
+)
+
+// LoadUserConfig reads the user config file, a missing file is an empty config
+func LoadUserConfig(file string) (*UserConfig, error) {
+	var cfg UserConfig
+
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return &cfg, nil
+	}
+
+	tree, err := toml.LoadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+
+	if err := tree.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+
+	return &cfg, nil
+}
+
+// Resolve returns the defaults overridden by the named profile
+func (c *UserConfig) Resolve(name string) (Profile, error) {
+	if name == "" {
+		return c.Profile, nil
+	}
+
+	named, ok := c.Profiles[name]
+	if !ok {
+		return c.Profile, fmt.Errorf("profile `%s` not found", name)
+	}
+
+	return c.Profile.Merge(named), nil
+}
+
+// Merge returns the profile with the values set in other overriding its own
+func (p Profile) Merge(other Profile) Profile {
+	merged := p
+	for dst, src := range map[*string]string{
+		&merged.TemplatesDir:    other.TemplatesDir,
+		&merged.CredentialsFile: other.CredentialsFile,
+		&merged.Calendar:        other.Calendar,
+		&merged.Timezone:        other.Timezone,
+		&merged.Output:          other.Output,
+	} {
+		if src != "" {
+			*dst = src
+		}
+	}
+
+	return merged
+}
+
+// Validate checks the output format and the timezone of the settings
+func (p Profile) Validate() error {
+	switch p.Output {
+	case "", OutputText, OutputJSON:
+	default:
+		return fmt.Errorf("invalid config `output` value: %s", p.Output)
+	}
+
+	if p.Timezone != "" {
+		if _, err := ResolveTimezone(p.Timezone); err != nil {
+			return fmt.Errorf("invalid config `timezone` value: %s: %s", p.Timezone, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package cursor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type (
+	// Cursor holds the last planned shift of a template rotation
+	Cursor struct {
+		Template      string    `json:"template"`
+		LastAssignees []string  `json:"last_assignees"`
+		LastDate      time.Time `json:"last_date"`
+	}
+
+	// Store reads and writes rotation cursors
+	// to the files in the cache directory
+	Store struct {
+		dir string
+	}
+)
+
+// NewStore inits a Store persisting cursors in `dir`
+func NewStore(dir string) Store {
+	return Store{dir}
+}
+
+// Load returns the persisted cursor of a template.
+// A nil cursor is returned if the template has not been planned yet
+func (s Store) Load(template string) (*Cursor, error) {
+	f, err := os.Open(s.file(template))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var c Cursor
+	if err := json.NewDecoder(f).Decode(&c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// Save persists the cursor overwriting the previous state
+func (s Store) Save(c *Cursor) error {
+	if err := os.MkdirAll(s.dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	f, err := os.Create(s.file(c.Template))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(c)
+}
+
+func (s Store) file(template string) string {
+	slug := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return '-'
+	}, template)
+
+	return filepath.Join(s.dir, slug+".json")
+}
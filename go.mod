@@ -6,4 +6,5 @@ require (
 	github.com/pelletier/go-toml v1.8.1
 	golang.org/x/oauth2 v0.0.0-20201208152858-08078c50e5b5
 	google.golang.org/api v0.36.0
+	gopkg.in/yaml.v2 v2.4.0
 )
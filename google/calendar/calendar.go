@@ -2,6 +2,10 @@ package calendar
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
 
 	"golang.org/x/oauth2"
 	"google.golang.org/api/calendar/v3"
@@ -12,7 +16,17 @@ import (
 	"github.com/makarski/gcaler/staff"
 )
 
-const eventDateTimeFormat = "2006-01-02T15:04:05-07:00"
+const (
+	eventDateTimeFormat = "2006-01-02T15:04:05-07:00"
+	eventDateFormat     = "2006-01-02"
+
+	// templateProperty is a private extended event property
+	// referencing the template the event was created from
+	templateProperty = "gcaler_template"
+
+	statusCancelled = "cancelled"
+	entryPointVideo = "video"
+)
 
 // GCalendar is a wrapper for Google Calendar Service
 type GCalendar struct {
@@ -40,37 +54,315 @@ func (gc GCalendar) CalendarEvent(
 	a staff.Assignment,
 	t *config.Template,
 ) (*calendar.Event, error) {
-	startTime := a.Date.Format(eventDateTimeFormat)
-	endTime := a.Date.Add(t.Duration).Format(eventDateTimeFormat)
-	tzName, _ := a.Date.UTC().Zone()
+	tzName, err := config.ResolveTimezone(t.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	tz, err := time.LoadLocation(tzName)
+	if err != nil {
+		return nil, err
+	}
+
+	// anchor the event and its recurrence in the template timezone
+	start := a.Date.In(tz)
+	startTime := start.Format(eventDateTimeFormat)
+	endTime := start.Add(t.Duration).Format(eventDateTimeFormat)
+
+	eRec, err := gcalEventRecurrence(a.SeriesOf(t), start)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &config.EventData{
+		Assignees: a.Assignees,
+		Host:      &t.EventHost,
+		Start:     start,
+		End:       start.Add(t.Duration),
+		Index:     a.Index,
+		Number:    a.Index + 1,
+		Previous:  a.Previous,
+		Next:      a.Next,
+	}
 
-	eRec, err := gcalEventRecurrence(&t.Recurrence)
+	summary, err := t.RenderEventTitle(data)
 	if err != nil {
 		return nil, err
 	}
 
+	description, err := config.RenderDescription(eventDescription(a.Assignees, t.Description), data)
+	if err != nil {
+		return nil, err
+	}
+
+	conference, err := eventConference(t.Conference)
+	if err != nil {
+		return nil, err
+	}
+
+	eStart := &calendar.EventDateTime{DateTime: startTime, TimeZone: tzName}
+	eEnd := &calendar.EventDateTime{DateTime: endTime, TimeZone: tzName}
+
+	// all-day events span whole days, the end date is exclusive
+	if t.AllDay {
+		eStart = &calendar.EventDateTime{Date: start.Format(eventDateFormat), TimeZone: tzName}
+		eEnd = &calendar.EventDateTime{Date: start.AddDate(0, 0, t.Days()).Format(eventDateFormat), TimeZone: tzName}
+	}
+
 	return &calendar.Event{
-		Summary:     t.GenerateEventTitle(append(a.Assignees, &t.EventHost)...),
-		Description: eventDescription(a.Assignees, t.Description),
-		Start: &calendar.EventDateTime{
-			DateTime: startTime,
-			TimeZone: tzName,
-		},
-		End: &calendar.EventDateTime{
-			DateTime: endTime,
-			TimeZone: tzName,
-		},
-		Attendees:    eventAttendees(t.EventHost.Email, a.Assignees),
+		Summary:      summary,
+		Description:  description,
+		Start:        eStart,
+		End:          eEnd,
+		Attendees:    eventAttendees(t, a.Assignees),
 		Transparency: t.Transparency,
 		Visibility:   t.Visibility,
 		Recurrence:   eRec,
+		Reminders:    eventReminders(t.EventReminders(a.Assignees...)),
+		ExtendedProperties: &calendar.EventExtendedProperties{
+			Private: map[string]string{templateProperty: t.Name},
+		},
+		ConferenceData:          conference,
+		Location:                t.Location,
+		ColorId:                 t.EventColor(a.Assignees...),
+		Attachments:             eventAttachments(t.Attachments),
+		GuestsCanModify:         t.GuestsCanModify,
+		GuestsCanInviteOthers:   t.GuestsCanInviteOthers,
+		GuestsCanSeeOtherGuests: t.GuestsCanSeeOtherGuests,
 	}, nil
 }
 
-func eventAttendees(hostEmail string, atds []*config.Assignee) []*calendar.EventAttendee {
-	attendees := []*calendar.EventAttendee{{Email: hostEmail, ResponseStatus: "accepted"}}
+// eventConference requests a conference of the given solution type.
+// The request id is unique per event, so that each event gets its own conference
+func eventConference(solution string) (*calendar.ConferenceData, error) {
+	if solution == "" {
+		return nil, nil
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	return &calendar.ConferenceData{
+		CreateRequest: &calendar.CreateConferenceRequest{
+			RequestId:             hex.EncodeToString(id),
+			ConferenceSolutionKey: &calendar.ConferenceSolutionKey{Type: solution},
+		},
+	}, nil
+}
+
+// JoinLink returns the video entry point of the event conference
+func JoinLink(event *calendar.Event) string {
+	if event.ConferenceData == nil {
+		return ""
+	}
+
+	for _, entry := range event.ConferenceData.EntryPoints {
+		if entry.EntryPointType == entryPointVideo {
+			return entry.Uri
+		}
+	}
+
+	return event.HangoutLink
+}
+
+func eventAttachments(urls []string) []*calendar.EventAttachment {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	attachments := make([]*calendar.EventAttachment, 0, len(urls))
+	for _, u := range urls {
+		attachments = append(attachments, &calendar.EventAttachment{FileUrl: u})
+	}
+
+	return attachments
+}
+
+// eventReminders maps the template reminders,
+// nil keeps the default reminders of the calendar
+func eventReminders(reminders []*config.Reminder) *calendar.EventReminders {
+	if reminders == nil {
+		return nil
+	}
+
+	if config.UsesDefault(reminders) {
+		return &calendar.EventReminders{UseDefault: true}
+	}
+
+	overrides := make([]*calendar.EventReminder, 0, len(reminders))
+	for _, reminder := range reminders {
+		overrides = append(overrides, &calendar.EventReminder{
+			Method:          reminder.Method,
+			Minutes:         reminder.Minutes,
+			ForceSendFields: []string{"Minutes"},
+		})
+	}
+
+	return &calendar.EventReminders{
+		Overrides:       overrides,
+		ForceSendFields: []string{"UseDefault"},
+	}
+}
+
+// ShiftHistory returns the assignments of the events created
+// from the template starting in the [since, until) range.
+// Events created before the template reference was stored
+// are recognized by the static prefix of the event title
+func (gc GCalendar) ShiftHistory(
+	ctx context.Context,
+	calSrv *calendar.Service,
+	t *config.Template,
+	since, until time.Time,
+) ([]staff.Assignment, error) {
+	participants := make(map[string]*config.Assignee, len(t.Participants))
+	for _, participant := range t.Participants {
+		participants[participant.Email] = participant
+	}
+
+	assignments := make([]staff.Assignment, 0)
+	err := calSrv.Events.
+		List(t.CalID).
+		TimeMin(since.Format(time.RFC3339)).
+		TimeMax(until.Format(time.RFC3339)).
+		SingleEvents(true).
+		OrderBy("startTime").
+		Pages(ctx, func(events *calendar.Events) error {
+			for _, event := range events.Items {
+				if event.Status == statusCancelled || !isTemplateEvent(event, t) {
+					continue
+				}
+
+				start, err := eventStart(event)
+				if err != nil {
+					return err
+				}
+
+				assignees := make(staff.Assignees, 0)
+				for _, attendee := range event.Attendees {
+					if participant, ok := participants[attendee.Email]; ok {
+						assignees = append(assignees, participant)
+					}
+				}
+
+				assignments = append(assignments, staff.Assignment{Assignees: assignees, Date: start})
+			}
+			return nil
+		})
+
+	return assignments, err
+}
+
+// Busy returns the busy periods of the calendars
+// in the given time range using the FreeBusy API.
+// Calendars which could not be queried are reported as unreadable
+func (gc GCalendar) Busy(
+	ctx context.Context,
+	calSrv *calendar.Service,
+	emails []string,
+	start, end time.Time,
+) (staff.Busy, error) {
+	items := make([]*calendar.FreeBusyRequestItem, 0, len(emails))
+	for _, email := range emails {
+		items = append(items, &calendar.FreeBusyRequestItem{Id: email})
+	}
+
+	resp, err := calSrv.Freebusy.Query(&calendar.FreeBusyRequest{
+		TimeMin: start.Format(time.RFC3339),
+		TimeMax: end.Format(time.RFC3339),
+		Items:   items,
+	}).Context(ctx).Do()
+	if err != nil {
+		return staff.Busy{}, err
+	}
+
+	busy := staff.Busy{
+		Periods:    make(map[string][]staff.Period, len(resp.Calendars)),
+		Unreadable: make(map[string]string),
+	}
+
+	for email, cal := range resp.Calendars {
+		if len(cal.Errors) > 0 {
+			busy.Unreadable[email] = cal.Errors[0].Reason
+			continue
+		}
+
+		for _, period := range cal.Busy {
+			periodStart, err := time.Parse(time.RFC3339, period.Start)
+			if err != nil {
+				return staff.Busy{}, err
+			}
+
+			periodEnd, err := time.Parse(time.RFC3339, period.End)
+			if err != nil {
+				return staff.Busy{}, err
+			}
+
+			busy.Periods[email] = append(busy.Periods[email], staff.Period{Start: periodStart, End: periodEnd})
+		}
+	}
+
+	return busy, nil
+}
+
+func isTemplateEvent(event *calendar.Event, t *config.Template) bool {
+	if event.ExtendedProperties != nil {
+		if name, ok := event.ExtendedProperties.Private[templateProperty]; ok {
+			return name == t.Name
+		}
+	}
+
+	// events created before the extended property are matched by the title,
+	// templated titles are matched by their static prefix
+	prefix := t.EventTitle
+	if i := strings.Index(prefix, "{{"); i >= 0 {
+		prefix = prefix[:i]
+	}
+
+	return strings.TrimSpace(prefix) != "" && strings.HasPrefix(event.Summary, prefix)
+}
+
+func eventStart(event *calendar.Event) (time.Time, error) {
+	if event.Start.DateTime == "" {
+		return time.Parse(eventDateFormat, event.Start.Date)
+	}
+
+	return time.Parse(time.RFC3339, event.Start.DateTime)
+}
+
+// eventAttendees lists the host, the assignees, the static attendees and the rooms
+// eventAttendees lists the host, the assignees, the static attendees and the rooms.
+// An email is invited once, ex: the host taking a shift keeps the host entry
+func eventAttendees(t *config.Template, atds []*config.Assignee) []*calendar.EventAttendee {
+	attendees := make([]*calendar.EventAttendee, 0, len(atds)+len(t.Attendees)+len(t.Rooms)+1)
+	seen := make(map[string]bool)
+
+	add := func(attendee *calendar.EventAttendee) {
+		email := strings.ToLower(attendee.Email)
+		if seen[email] {
+			return
+		}
+		seen[email] = true
+		attendees = append(attendees, attendee)
+	}
+
+	add(&calendar.EventAttendee{Email: t.EventHost.Email, ResponseStatus: "accepted"})
 	for _, atd := range atds {
-		attendees = append(attendees, &calendar.EventAttendee{Email: atd.Email, ResponseStatus: "needsAction"})
+		add(&calendar.EventAttendee{Email: atd.Email, ResponseStatus: "needsAction", Optional: atd.Optional})
+	}
+
+	for _, atd := range t.Attendees {
+		add(&calendar.EventAttendee{
+			Email:          atd.Email,
+			DisplayName:    atd.Name,
+			ResponseStatus: "needsAction",
+			Optional:       atd.Optional,
+		})
+	}
+
+	for _, room := range t.Rooms {
+		add(&calendar.EventAttendee{Email: room, ResponseStatus: "needsAction", Resource: true})
 	}
 
 	return attendees
@@ -84,10 +376,10 @@ func eventDescription(assignees []*config.Assignee, generic string) string {
 	return assignees[0].Description
 }
 
-func gcalEventRecurrence(r *config.Recurrence) ([]string, error) {
+func gcalEventRecurrence(r *config.Recurrence, start time.Time) ([]string, error) {
 	if r.Mode.IsSingle() {
 		return nil, nil
 	}
 
-	return r.RFC5545()
+	return r.RFC5545(start)
 }
@@ -0,0 +1,238 @@
+package calendar
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+
+	"github.com/makarski/gcaler/config"
+	"github.com/makarski/gcaler/staff"
+)
+
+func TestCalendarEventDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &config.Template{
+		Name:       "on-call",
+		EventTitle: "On-call",
+		Timezone:   "Europe/Berlin",
+		Duration:   8 * time.Hour,
+		Recurrence: config.Recurrence{Mode: config.RecModeSingle},
+		EventHost:  config.Assignee{Email: "host@example.com"},
+	}
+
+	tests := []struct {
+		name      string
+		date      time.Time
+		wantStart string
+		wantEnd   string
+	}{
+		{"before the october transition", time.Date(2026, 10, 24, 9, 0, 0, 0, berlin), "2026-10-24T09:00:00+02:00", "2026-10-24T17:00:00+02:00"},
+		{"after the october transition", time.Date(2026, 10, 26, 9, 0, 0, 0, berlin), "2026-10-26T09:00:00+01:00", "2026-10-26T17:00:00+01:00"},
+		{"before the march transition", time.Date(2027, 3, 27, 9, 0, 0, 0, berlin), "2027-03-27T09:00:00+01:00", "2027-03-27T17:00:00+01:00"},
+		{"after the march transition", time.Date(2027, 3, 29, 9, 0, 0, 0, berlin), "2027-03-29T09:00:00+02:00", "2027-03-29T17:00:00+02:00"},
+		{"utc date in summer time", time.Date(2026, 10, 24, 7, 0, 0, 0, time.UTC), "2026-10-24T09:00:00+02:00", "2026-10-24T17:00:00+02:00"},
+		{"utc date in winter time", time.Date(2026, 10, 26, 8, 0, 0, 0, time.UTC), "2026-10-26T09:00:00+01:00", "2026-10-26T17:00:00+01:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assignment := staff.Assignment{
+				Assignees: staff.Assignees{{FirstName: "Jane", Email: "jane@example.com"}},
+				Date:      tt.date,
+			}
+
+			event, err := GCalendar{}.CalendarEvent(assignment, template)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if event.Start.DateTime != tt.wantStart {
+				t.Errorf("start: got %s, want %s", event.Start.DateTime, tt.wantStart)
+			}
+
+			if event.End.DateTime != tt.wantEnd {
+				t.Errorf("end: got %s, want %s", event.End.DateTime, tt.wantEnd)
+			}
+
+			if event.Start.TimeZone != "Europe/Berlin" || event.End.TimeZone != "Europe/Berlin" {
+				t.Errorf("timezone: got %s / %s, want Europe/Berlin", event.Start.TimeZone, event.End.TimeZone)
+			}
+		})
+	}
+}
+
+func TestIsTemplateEvent(t *testing.T) {
+	tests := []struct {
+		name       string
+		eventTitle string
+		event      *calendar.Event
+		want       bool
+	}{
+		{
+			name:       "template property",
+			eventTitle: "On-call",
+			event: &calendar.Event{
+				Summary:            "Other",
+				ExtendedProperties: &calendar.EventExtendedProperties{Private: map[string]string{templateProperty: "on-call"}},
+			},
+			want: true,
+		},
+		{
+			name:       "other template property",
+			eventTitle: "On-call",
+			event: &calendar.Event{
+				Summary:            "On-call",
+				ExtendedProperties: &calendar.EventExtendedProperties{Private: map[string]string{templateProperty: "support"}},
+			},
+			want: false,
+		},
+		{"plain title", "On-call", &calendar.Event{Summary: "On-call: Jane Doe"}, true},
+		{"templated title prefix", "On-call #{{ .Number }}: {{ names .Assignees }}", &calendar.Event{Summary: "On-call #3: Jane Doe"}, true},
+		{"templated title mismatch", "On-call #{{ .Number }}", &calendar.Event{Summary: "Standup"}, false},
+		{"title without a static prefix", "{{ names .Assignees }}", &calendar.Event{Summary: "Jane Doe"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := &config.Template{Name: "on-call", EventTitle: tt.eventTitle}
+			if got := isTemplateEvent(tt.event, template); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventAttendees(t *testing.T) {
+	host := config.Assignee{Email: "host@example.com"}
+	alice := &config.Assignee{Email: "alice@example.com", Optional: true}
+
+	template := &config.Template{
+		EventHost: host,
+		Attendees: []*config.Attendee{{Email: "team@example.com", Name: "Team"}},
+		Rooms:     []string{"room@resource.example.com"},
+	}
+
+	tests := []struct {
+		name      string
+		assignees []*config.Assignee
+		want      []string
+	}{
+		{"all attendees", []*config.Assignee{alice}, []string{"host@example.com", "alice@example.com", "team@example.com", "room@resource.example.com"}},
+		{"host taking the shift", []*config.Assignee{{Email: "Host@example.com"}}, []string{"host@example.com", "team@example.com", "room@resource.example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attendees := eventAttendees(template, tt.assignees)
+
+			got := make([]string, 0, len(attendees))
+			for _, attendee := range attendees {
+				got = append(got, attendee.Email)
+			}
+
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalendarEventAllDay(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the template is loaded from a file to set up the all-day rule dates
+	const allDayTemplate = `event_title = "Holiday duty"
+timezone = "Europe/Berlin"
+all_day = true
+duration = "%s"
+participants = [{ first_name = "Jane", email = "jane@example.com" }]
+
+[host]
+email = "host@example.com"
+
+[recurrence]
+%s
+`
+
+	tests := []struct {
+		name           string
+		date           time.Time
+		duration       string
+		recurrence     string
+		wantStart      string
+		wantEnd        string
+		wantRecurrence []string
+	}{
+		{
+			name:       "single day, the end date is exclusive",
+			date:       time.Date(2026, 12, 25, 0, 0, 0, 0, berlin),
+			duration:   "24h",
+			recurrence: `mode = "single"`,
+			wantStart:  "2026-12-25",
+			wantEnd:    "2026-12-26",
+		},
+		{
+			name:       "week across the october transition",
+			date:       time.Date(2026, 10, 19, 0, 0, 0, 0, berlin),
+			duration:   "168h",
+			recurrence: `mode = "single"`,
+			wantStart:  "2026-10-19",
+			wantEnd:    "2026-10-26",
+		},
+		{
+			name:           "weekly series",
+			date:           time.Date(2026, 11, 2, 0, 0, 0, 0, berlin),
+			duration:       "24h",
+			recurrence:     "mode = \"recurrent\"\ncount = -1\nfreq = \"weekly\"\nuntil = \"2026-11-30\"\nexdates = [\"2026-11-16\"]",
+			wantStart:      "2026-11-02",
+			wantEnd:        "2026-11-03",
+			wantRecurrence: []string{"RRULE:FREQ=WEEKLY;UNTIL=20261130", "EXDATE;VALUE=DATE:20261116"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "holiday-duty.toml")
+			content := fmt.Sprintf(allDayTemplate, tt.duration, tt.recurrence)
+			if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			template, err := config.LoadTemplate(file, config.Defaults{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assignment := staff.Assignment{Assignees: template.Participants, Date: tt.date}
+
+			event, err := GCalendar{}.CalendarEvent(assignment, template)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if event.Start.DateTime != "" || event.End.DateTime != "" {
+				t.Errorf("date times are set: %s - %s", event.Start.DateTime, event.End.DateTime)
+			}
+
+			if event.Start.Date != tt.wantStart || event.End.Date != tt.wantEnd {
+				t.Errorf("got %s - %s, want %s - %s", event.Start.Date, event.End.Date, tt.wantStart, tt.wantEnd)
+			}
+
+			if strings.Join(event.Recurrence, " ") != strings.Join(tt.wantRecurrence, " ") {
+				t.Errorf("recurrence: got %v, want %v", event.Recurrence, tt.wantRecurrence)
+			}
+		})
+	}
+}
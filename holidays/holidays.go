@@ -0,0 +1,221 @@
+package holidays
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml"
+
+	"github.com/makarski/gcaler/rrule"
+)
+
+const (
+	dateFormat = "2006-01-02"
+
+	// recurring events are expanded up to
+	// the horizon or the occurrences limit
+	recurrenceHorizonYears = 10
+	maxOccurrences         = 10000
+)
+
+type (
+	// Holidays maps dates in "2006-01-02" format to holiday names
+	Holidays map[string]string
+
+	// Holiday is an entry of a TOML holidays file
+	Holiday struct {
+		Date string `toml:"date"`
+		Name string `toml:"name"`
+	}
+
+	tomlFile struct {
+		Holidays []Holiday `toml:"holidays"`
+	}
+)
+
+// Load reads a local holidays file: ICS calendar export or TOML list
+func Load(file string) (Holidays, error) {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".ics":
+		return loadICS(file)
+	case ".toml":
+		return loadTOML(file)
+	}
+
+	return nil, fmt.Errorf("unsupported holidays file format: %s", file)
+}
+
+// Add registers a holiday on the date
+func (h Holidays) Add(date time.Time, name string) {
+	h[date.Format(dateFormat)] = name
+}
+
+// Lookup returns the holiday name of the date
+func (h Holidays) Lookup(date time.Time) (string, bool) {
+	name, ok := h[date.Format(dateFormat)]
+	return name, ok
+}
+
+func loadTOML(file string) (Holidays, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var cfg tomlFile
+	if err := toml.NewDecoder(f).Decode(&cfg); err != nil {
+		return nil, err
+	}
+
+	holidays := make(Holidays, len(cfg.Holidays))
+	for _, holiday := range cfg.Holidays {
+		date, err := time.Parse(dateFormat, holiday.Date)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid holiday date: %s", file, holiday.Date)
+		}
+		holidays.Add(date, holiday.Name)
+	}
+
+	return holidays, nil
+}
+
+// loadICS reads the all-day events of an iCalendar file.
+// Multi-day events mark every day until the exclusive DTEND,
+// recurring events mark every occurrence until the expansion horizon
+func loadICS(file string) (Holidays, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	holidays := make(Holidays)
+
+	var (
+		start, end time.Time
+		name       string
+		recurrence []string
+
+		// components is the stack of the open components,
+		// properties of the nested ones (ex: VALARM) are ignored
+		components []string
+	)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		key, value := splitICSLine(line)
+
+		if key == "BEGIN" {
+			components = append(components, strings.ToUpper(value))
+			if strings.ToUpper(value) == "VEVENT" {
+				start, end, name, recurrence = time.Time{}, time.Time{}, "", nil
+			}
+			continue
+		}
+
+		inEvent := len(components) > 0 && components[len(components)-1] == "VEVENT"
+
+		if key == "END" {
+			if len(components) > 0 {
+				components = components[:len(components)-1]
+			}
+
+			if !inEvent || start.IsZero() {
+				continue
+			}
+
+			if err := holidays.addEvent(start, end, name, recurrence); err != nil {
+				return nil, fmt.Errorf("%s: %s: %s", file, name, err)
+			}
+			continue
+		}
+
+		if !inEvent {
+			continue
+		}
+
+		var err error
+		switch key {
+		case "DTSTART":
+			start, err = parseICSDate(value)
+		case "DTEND":
+			end, err = parseICSDate(value)
+		case "SUMMARY":
+			name = value
+		case "RRULE", "EXDATE", "RDATE":
+			recurrence = append(recurrence, line)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file, err)
+		}
+	}
+
+	return holidays, scanner.Err()
+}
+
+// addEvent marks the days of the event and of its recurrence occurrences
+func (h Holidays) addEvent(start, end time.Time, name string, recurrence []string) error {
+	if end.IsZero() || !end.After(start) {
+		end = start.AddDate(0, 0, 1)
+	}
+
+	days := int(end.Sub(start).Hours() / 24)
+	if days < 1 {
+		days = 1
+	}
+
+	dates := []time.Time{start}
+	if len(recurrence) > 0 {
+		set, err := rrule.Parse(start, recurrence)
+		if err != nil {
+			return err
+		}
+
+		// unlimited yearly holidays repeat forever, stop at the horizon
+		horizon := time.Now().AddDate(recurrenceHorizonYears, 0, 0)
+		if set.Rule != nil && (set.Rule.Until.IsZero() || set.Rule.Until.After(horizon)) {
+			set.Rule.Until = horizon
+		}
+
+		dates = set.Occurrences(maxOccurrences)
+	}
+
+	for _, date := range dates {
+		for i := 0; i < days; i++ {
+			h.Add(date.AddDate(0, 0, i), name)
+		}
+	}
+
+	return nil
+}
+
+// splitICSLine splits a content line into the property name
+// without parameters and the value: "DTSTART;VALUE=DATE:20211225"
+func splitICSLine(line string) (string, string) {
+	i := strings.Index(line, ":")
+	if i < 0 {
+		return "", ""
+	}
+
+	name := line[:i]
+	if j := strings.Index(name, ";"); j >= 0 {
+		name = name[:j]
+	}
+
+	return strings.ToUpper(name), line[i+1:]
+}
+
+func parseICSDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("invalid date: %s", value)
+	}
+
+	return time.Parse("20060102", value[:8])
+}
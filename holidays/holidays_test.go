@@ -0,0 +1,113 @@
+package holidays
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadICS(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		missing []string
+		wantErr bool
+	}{
+		{
+			name: "event with a nested alarm",
+			content: `BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART;VALUE=DATE:20261225
+DTEND;VALUE=DATE:20261227
+SUMMARY:Christmas
+BEGIN:VALARM
+TRIGGER:-PT15M
+SUMMARY:Reminder
+END:VALARM
+END:VEVENT
+END:VCALENDAR
+`,
+			want: map[string]string{"2026-12-25": "Christmas", "2026-12-26": "Christmas"},
+		},
+		{
+			name: "yearly event with an excluded date",
+			content: `BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART;VALUE=DATE:20200501
+RRULE:FREQ=YEARLY
+EXDATE;VALUE=DATE:20270501
+SUMMARY:Labour Day
+END:VEVENT
+END:VCALENDAR
+`,
+			want:    map[string]string{"2020-05-01": "Labour Day", "2026-05-01": "Labour Day", "2028-05-01": "Labour Day"},
+			missing: []string{"2027-05-01"},
+		},
+		{
+			name: "counted recurrence",
+			content: `BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART;VALUE=DATE:20261102
+RRULE:FREQ=WEEKLY;COUNT=2
+SUMMARY:Bridge day
+END:VEVENT
+END:VCALENDAR
+`,
+			want:    map[string]string{"2026-11-02": "Bridge day", "2026-11-09": "Bridge day"},
+			missing: []string{"2026-11-16"},
+		},
+		{
+			name: "unsupported rule part",
+			content: `BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART;VALUE=DATE:20201225
+RRULE:FREQ=YEARLY;BYMONTH=12
+SUMMARY:Christmas
+END:VEVENT
+END:VCALENDAR
+`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "holidays.ics")
+			if err := ioutil.WriteFile(file, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			holidays, err := Load(file)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error: got %v, want error %t", err, tt.wantErr)
+			}
+
+			for date, want := range tt.want {
+				if got := holidays[date]; got != want {
+					t.Errorf("%s: got %q, want %q", date, got, want)
+				}
+			}
+
+			for _, date := range tt.missing {
+				if _, ok := holidays[date]; ok {
+					t.Errorf("%s: unexpected holiday", date)
+				}
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	holidays := Holidays{"2026-12-25": "Christmas"}
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if name, ok := holidays.Lookup(time.Date(2026, 12, 25, 9, 0, 0, 0, berlin)); !ok || name != "Christmas" {
+		t.Errorf("got %q %t, want Christmas", name, ok)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -9,14 +10,27 @@ import (
 	"github.com/makarski/gcaler/cmd"
 	"github.com/makarski/gcaler/cmd/list"
 	"github.com/makarski/gcaler/cmd/plan"
+	"github.com/makarski/gcaler/cmd/template"
+	"github.com/makarski/gcaler/cmd/validate"
+	"github.com/makarski/gcaler/config"
 	"github.com/makarski/gcaler/google/auth"
 	gcal "github.com/makarski/gcaler/google/calendar"
 )
 
 const (
-	appName     = "gcaler"
-	planCmdName = "plan"
-	listCmdName = "list"
+	appName         = "gcaler"
+	userConfigFile  = "config.toml"
+	planCmdName     = "plan"
+	listCmdName     = "list"
+	templateCmdName = "template"
+	validateCmdName = "validate"
+
+	envProfile     = "GCALER_PROFILE"
+	envTemplates   = "GCALER_TEMPLATES"
+	envCredentials = "GCALER_CREDENTIALS"
+	envCalendar    = "GCALER_CALENDAR"
+	envTimezone    = "GCALER_TIMEZONE"
+	envOutput      = "GCALER_OUTPUT"
 )
 
 var (
@@ -25,29 +39,87 @@ var (
 	tokenCacheDir  string
 	tokenCacheFile string
 
-	templatesDir    string
-	credentialsFile string
-	calId           string
+	profileName string
+	// flagSettings are the settings provided as flags
+	flagSettings config.Profile
+	// settings are resolved in the order: flags > env > profile > user defaults > defaults
+	settings config.Profile
 )
 
 func init() {
-	wd, err := os.Getwd()
-	if err != nil {
-		panic(err)
-	}
-
 	tokenCacheDir = filepath.Join(os.Getenv("HOME"), "."+appName)
 	tokenCacheFile = filepath.Join(tokenCacheDir, "access_token.json")
 
 	fls = flag.NewFlagSet("", flag.ExitOnError)
 
-	fls.StringVar(&templatesDir, "templates", filepath.Join(wd, "templates"), "Path to templates directory")
-	fls.StringVar(&credentialsFile, "credentials", filepath.Join(wd, "client_secret.json"), "Credentials file name: absolute or relative path")
-	fls.StringVar(&calId, "email", calId, "Optional: email (calendar id) - used for 'list' subcmd")
+	fls.StringVar(&profileName, "profile", "", "Optional: profile of the user config "+userConfigFile+", env: "+envProfile)
+	fls.StringVar(&flagSettings.TemplatesDir, "templates", "", "Path to templates directory, default: ./templates, env: "+envTemplates)
+	fls.StringVar(&flagSettings.CredentialsFile, "credentials", "", "Credentials file name: absolute or relative path, default: ./client_secret.json, env: "+envCredentials)
+	fls.StringVar(&flagSettings.Calendar, "email", "", "Optional: email (calendar id) - used for 'list' subcmd and templates without `cal_id`, env: "+envCalendar)
+	fls.StringVar(&flagSettings.Timezone, "timezone", "", "Optional: timezone of 'list' subcmd and templates without `timezone`, env: "+envTimezone)
+	fls.StringVar(&flagSettings.Output, "output", "", "Optional: 'list' output format: text, json, env: "+envOutput)
 
 	fls.Usage = printHelp
 }
 
+// resolveSettings merges the flags, the environment,
+// the user config profile and the defaults
+func resolveSettings() error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	userCfg, err := config.LoadUserConfig(filepath.Join(tokenCacheDir, userConfigFile))
+	if err != nil {
+		return err
+	}
+
+	if profileName == "" {
+		profileName = os.Getenv(envProfile)
+	}
+
+	profile, err := userCfg.Resolve(profileName)
+	if err != nil {
+		return err
+	}
+
+	env := config.Profile{
+		TemplatesDir:    os.Getenv(envTemplates),
+		CredentialsFile: os.Getenv(envCredentials),
+		Calendar:        os.Getenv(envCalendar),
+		Timezone:        os.Getenv(envTimezone),
+		Output:          os.Getenv(envOutput),
+	}
+
+	defaults := config.Profile{
+		TemplatesDir:    filepath.Join(wd, "templates"),
+		CredentialsFile: filepath.Join(wd, "client_secret.json"),
+		Output:          config.OutputText,
+	}
+
+	settings = defaults.Merge(profile).Merge(env).Merge(flagSettings)
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// templateDefaults are the settings used for the keys missing in the templates
+func templateDefaults() config.Defaults {
+	return config.Defaults{
+		CalID:    settings.Calendar,
+		Timezone: settings.Timezone,
+	}
+}
+
+// exit prints the error and exits with a failure status
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
 func printHelp() {
 	txt := `
 Calendar planner
@@ -57,7 +129,15 @@ USAGE:
 
 SUBCOMMANDS:
   plan		Schedule an based on the template config
+		  -template {name|path}	template to plan, prompted if omitted
+		  -start 2026-11-02T09:00	first event date, prompted if omitted
+		  -assign "0,1 2,email"	manual rotation picks per date, prompted if omitted
+		  -yes			insert recurring series without a confirmation
   list		List calendar events
+  template	Template tools:
+		  show {name}	print the template merged with the templates it extends
+		  new {name}	create a template interactively
+  validate	Validate all templates, or the given {name}s, without google credentials
 
 OPTIONS:
 `
@@ -70,19 +150,46 @@ func main() {
 	// parse flags
 	fls.Parse(os.Args[1:])
 
+	if err := resolveSettings(); err != nil {
+		exit(err)
+	}
+
 	// parse subcommand
 	cmdName := fls.Arg(0)
 
+	// local subcommands do not require google credentials
+	localRun, err := func() (cmd.LocalCmdFunc, error) {
+		switch cmdName {
+		case templateCmdName:
+			return template.Template(settings.TemplatesDir, templateDefaults(), fls.Args()[1:], accountEmail)
+		case validateCmdName:
+			return validate.Validate(settings.TemplatesDir, fls.Args()[1:]), nil
+		}
+		return nil, nil
+	}()
+
+	if err != nil {
+		exit(err)
+	}
+
+	// local subcommands are used in scripts and hooks: exit with a status code
+	if localRun != nil {
+		if err := localRun(); err != nil {
+			exit(err)
+		}
+		return
+	}
+
 	cmdRun, err := func() (cmd.CmdFunc, error) {
 		switch cmdName {
 		case planCmdName:
-			return plan.Plan(templatesDir), nil
+			return plan.Plan(settings.TemplatesDir, tokenCacheDir, templateDefaults(), fls.Args()[1:]), nil
 		case listCmdName, "":
-			if calId == "" {
-				return nil, fmt.Errorf("`-email` option must be provided")
+			if settings.Calendar == "" {
+				return nil, fmt.Errorf("a calendar must be provided: `-email` option, %s env or `calendar` in the user config", envCalendar)
 			}
 
-			return list.List(calId), nil
+			return list.List(settings.Calendar, settings.Timezone, settings.Output), nil
 		}
 		return nil, fmt.Errorf("cmd: `%s` not found", cmdName)
 	}()
@@ -91,16 +198,33 @@ func main() {
 		panic(err)
 	}
 
-	gToken := auth.NewGToken(credentialsFile, tokenCacheFile, tokenCacheDir)
-	credCfg, err := gToken.Credentials()
+	gCalendar, err := newGCalendar()
 	if err != nil {
 		panic(err)
 	}
 
-	gCalendar := gcal.NewGCalerndar(&gToken, credCfg)
-
 	// execute
 	if err := cmdRun(gCalendar); err != nil {
 		panic(err)
 	}
 }
+
+func newGCalendar() (gcal.GCalendar, error) {
+	gToken := auth.NewGToken(settings.CredentialsFile, tokenCacheFile, tokenCacheDir)
+	credCfg, err := gToken.Credentials()
+	if err != nil {
+		return gcal.GCalendar{}, err
+	}
+
+	return gcal.NewGCalerndar(&gToken, credCfg), nil
+}
+
+// accountEmail loads the google credentials on demand of the local subcommands
+func accountEmail() (string, error) {
+	gCalendar, err := newGCalendar()
+	if err != nil {
+		return "", err
+	}
+
+	return cmd.AccountEmail(context.Background(), &gCalendar)
+}
@@ -2,21 +2,219 @@ package planweek
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// Plan returns a channel of dates starting from startDate
-func Plan(ctx context.Context, startDate time.Time, eventCount int32, interval time.Duration) (<-chan time.Time, error) {
+const (
+	day = 24 * time.Hour
+
+	// maxSkips limits the consecutive skipped dates
+	// to stop planning when a filter rejects every date
+	maxSkips = 1000
+)
+
+var calendarStepRe = regexp.MustCompile(`^(\d+)(d|w|mo|y)$`)
+
+// Step describes the distance between two planned dates.
+// Days and months are calendar units: they preserve the wall clock time
+// and the day of month in the date location across DST transitions
+type (
+	Step struct {
+		Fixed  time.Duration
+		Days   int
+		Months int
+	}
+
+	// Filter rejects the dates which are not business days
+	Filter struct {
+		SkipWeekends bool
+		// Weekdays lists the allowed weekdays, all are allowed if empty
+		Weekdays []time.Weekday
+		// Holiday returns the holiday name if the date is a holiday
+		Holiday func(time.Time) (string, bool)
+	}
+
+	// Skipped describes a date rejected by the filter
+	Skipped struct {
+		Date   time.Time
+		Reason string
+	}
+)
+
+// ParseStep parses calendar steps: "1d", "2w", "1mo", "1y"
+// or go durations: "90m", "24h". Durations of whole days
+// are converted to calendar days
+func ParseStep(s string) (Step, error) {
+	if m := calendarStepRe.FindStringSubmatch(s); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return Step{}, err
+		}
+
+		switch m[2] {
+		case "d":
+			return Step{Days: n}, nil
+		case "w":
+			return Step{Days: 7 * n}, nil
+		case "mo":
+			return Step{Months: n}, nil
+		case "y":
+			return Step{Months: 12 * n}, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return Step{}, fmt.Errorf("invalid step: %s", s)
+	}
+
+	if d != 0 && d%day == 0 {
+		return Step{Days: int(d / day)}, nil
+	}
+
+	return Step{Fixed: d}, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (s *Step) UnmarshalText(text []byte) error {
+	step, err := ParseStep(string(text))
+	if err != nil {
+		return err
+	}
+
+	*s = step
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s Step) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s Step) String() string {
+	parts := make([]string, 0, 3)
+	switch {
+	case s.Months > 0 && s.Months%12 == 0:
+		parts = append(parts, fmt.Sprintf("%dy", s.Months/12))
+	case s.Months > 0:
+		parts = append(parts, fmt.Sprintf("%dmo", s.Months))
+	}
+
+	switch {
+	case s.Days > 0 && s.Days%7 == 0:
+		parts = append(parts, fmt.Sprintf("%dw", s.Days/7))
+	case s.Days > 0:
+		parts = append(parts, fmt.Sprintf("%dd", s.Days))
+	}
+
+	if s.Fixed != 0 || len(parts) == 0 {
+		parts = append(parts, s.Fixed.String())
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// IsZero checks whether the step does not advance dates
+func (s Step) IsZero() bool {
+	return s.Fixed == 0 && s.Days == 0 && s.Months == 0
+}
+
+// Approx returns the step as a duration assuming 30 days months
+// and 24 hours days
+func (s Step) Approx() time.Duration {
+	return s.Fixed + time.Duration(s.Days)*day + time.Duration(s.Months)*30*day
+}
+
+// Nth returns the date `n` steps after the start date.
+// Months are clamped to the last day of a shorter month:
+// Jan 31 + 1 month is Feb 28, Jan 31 + 2 months is Mar 31
+func (s Step) Nth(start time.Time, n int) time.Time {
+	date := start
+	if s.Months != 0 {
+		y, m, d := start.Date()
+		target := time.Date(y, m+time.Month(s.Months*n), 1, 0, 0, 0, 0, start.Location())
+		if last := daysIn(target.Year(), target.Month()); d > last {
+			d = last
+		}
+
+		date = time.Date(
+			target.Year(), target.Month(), d,
+			start.Hour(), start.Minute(), start.Second(), start.Nanosecond(),
+			start.Location(),
+		)
+	}
+
+	return date.AddDate(0, 0, s.Days*n).Add(s.Fixed * time.Duration(n))
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// Skip returns the reason why the date can not be planned
+func (f *Filter) Skip(date time.Time) (string, bool) {
+	if f == nil {
+		return "", false
+	}
+
+	if f.SkipWeekends && (date.Weekday() == time.Saturday || date.Weekday() == time.Sunday) {
+		return "weekend", true
+	}
+
+	if len(f.Weekdays) > 0 {
+		allowed := false
+		for _, wd := range f.Weekdays {
+			allowed = allowed || wd == date.Weekday()
+		}
+
+		if !allowed {
+			return date.Weekday().String() + " is not planned", true
+		}
+	}
+
+	if f.Holiday != nil {
+		if name, ok := f.Holiday(date); ok {
+			return "holiday: " + name, true
+		}
+	}
+
+	return "", false
+}
+
+// Plan returns a channel of `eventCount` dates starting from startDate.
+// Dates rejected by the filter do not count and are passed to onSkip
+func Plan(
+	ctx context.Context,
+	startDate time.Time,
+	eventCount int32,
+	step Step,
+	filter *Filter,
+	onSkip func(Skipped),
+) (<-chan time.Time, error) {
 	dates := make(chan time.Time)
-	go func(currentDate time.Time, eventCount int32) {
+	go func(startDate time.Time, eventCount int32) {
+		skips := 0
 	out:
-		for i := 0; i < int(eventCount); i++ {
+		for i, planned := 0, 0; planned < int(eventCount) && skips < maxSkips; i++ {
+			date := step.Nth(startDate, i)
+			if reason, skip := filter.Skip(date); skip {
+				skips++
+				onSkip(Skipped{Date: date, Reason: reason})
+				continue
+			}
+
+			skips = 0
+			planned++
+
+			// the consumer may stop reading early, ex: on an assignment error
 			select {
 			case <-ctx.Done():
 				break out
-			default:
-				dates <- currentDate
-				currentDate = currentDate.Add(interval)
+			case dates <- date:
 			}
 		}
 		close(dates)
@@ -0,0 +1,68 @@
+package planweek
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPlanStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	start := time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC)
+	dates, err := Plan(ctx, start, 10, Step{Days: 1}, &Filter{}, func(Skipped) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := <-dates; !got.Equal(start) {
+		t.Fatalf("got %s, want %s", got, start)
+	}
+
+	// the consumer stops reading: the producer must not block on the send
+	cancel()
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-dates:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("the dates channel is not closed after the cancellation")
+		}
+	}
+}
+
+func TestPlanSkipsFilteredDates(t *testing.T) {
+	start := time.Date(2026, 11, 6, 9, 0, 0, 0, time.UTC) // Friday
+
+	var skipped []Skipped
+	dates, err := Plan(context.Background(), start, 3, Step{Days: 1}, &Filter{SkipWeekends: true}, func(s Skipped) {
+		skipped = append(skipped, s)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"2026-11-06", "2026-11-09", "2026-11-10"}
+	got := make([]string, 0)
+	for date := range dates {
+		got = append(got, date.Format("2006-01-02"))
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+
+	if len(skipped) != 2 {
+		t.Errorf("got %d skipped dates, want 2", len(skipped))
+	}
+}
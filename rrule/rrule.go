@@ -0,0 +1,491 @@
+package rrule
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	dateTimeFormat = "20060102T150405Z"
+	dateFormat     = "20060102"
+
+	// maxPeriods stops the expansion of rules
+	// which do not produce any more occurrences
+	maxPeriods = 100000
+)
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+type (
+	// Weekday is a BYDAY entry: a weekday with an optional ordinal,
+	// ex: -1FR is the last Friday of the month or year
+	Weekday struct {
+		N   int
+		Day time.Weekday
+	}
+
+	// Rule is a parsed RRULE
+	Rule struct {
+		Freq       string
+		Interval   int
+		Count      int
+		Until      time.Time
+		ByDay      []Weekday
+		ByMonthDay []int
+		BySetPos   []int
+		Wkst       time.Weekday
+	}
+
+	// Set is a recurrence set: a start date, a rule
+	// and the excluded and additional dates
+	Set struct {
+		Start   time.Time
+		Rule    *Rule
+		ExDates []time.Time
+		RDates  []time.Time
+	}
+)
+
+// Parse reads RRULE, EXDATE and RDATE lines of a recurrence starting at `start`.
+// Occurrences are expanded in the location of the start date
+func Parse(start time.Time, lines []string) (*Set, error) {
+	set := Set{Start: start}
+
+	for _, line := range lines {
+		i := strings.Index(line, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid recurrence line: %s", line)
+		}
+
+		name, value := line[:i], line[i+1:]
+		if j := strings.Index(name, ";"); j >= 0 {
+			name = name[:j]
+		}
+
+		switch strings.ToUpper(name) {
+		case "RRULE":
+			rule, err := parseRule(value, start.Location())
+			if err != nil {
+				return nil, err
+			}
+			set.Rule = rule
+		case "EXDATE", "RDATE":
+			dates, err := parseDates(value, start.Location())
+			if err != nil {
+				return nil, err
+			}
+
+			if strings.ToUpper(name) == "EXDATE" {
+				set.ExDates = append(set.ExDates, dates...)
+			} else {
+				set.RDates = append(set.RDates, dates...)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported recurrence line: %s", line)
+		}
+	}
+
+	return &set, nil
+}
+
+// Occurrences returns up to `limit` first dates of the recurrence set
+func (s *Set) Occurrences(limit int) []time.Time {
+	excluded := make(map[int64]bool, len(s.ExDates))
+	for _, date := range s.ExDates {
+		excluded[date.Unix()] = true
+	}
+
+	dates := []time.Time{s.Start}
+	if s.Rule != nil {
+		dates = s.Rule.expand(s.Start, limit+len(s.ExDates))
+	}
+
+	loc := s.Start.Location()
+	for _, date := range s.RDates {
+		dates = append(dates, date.In(loc))
+	}
+
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	occurrences := make([]time.Time, 0, limit)
+	seen := make(map[int64]bool, len(dates))
+	for _, date := range dates {
+		if len(occurrences) == limit {
+			break
+		}
+
+		if excluded[date.Unix()] || seen[date.Unix()] {
+			continue
+		}
+
+		seen[date.Unix()] = true
+		occurrences = append(occurrences, date)
+	}
+
+	return occurrences
+}
+
+func parseRule(value string, loc *time.Location) (*Rule, error) {
+	rule := Rule{Interval: 1, Wkst: time.Monday}
+
+	for _, part := range strings.Split(value, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid rule part: %s", part)
+		}
+
+		key, val := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		var err error
+		switch key {
+		case "FREQ":
+			rule.Freq = val
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(val)
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(val)
+			if rule.Count == 0 {
+				// COUNT=0 yields no occurrences, mark it as negative
+				// to distinguish from the unlimited count
+				rule.Count = -1
+			}
+		case "UNTIL":
+			var until []time.Time
+			until, err = parseDates(val, loc)
+			if err == nil {
+				rule.Until = until[0]
+			}
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(val)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseInts(val)
+		case "BYSETPOS":
+			rule.BySetPos, err = parseInts(val)
+		case "WKST":
+			wd, ok := weekdays[val]
+			if !ok {
+				err = fmt.Errorf("invalid weekday")
+			}
+			rule.Wkst = wd
+		default:
+			err = fmt.Errorf("unsupported rule part")
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", part, err)
+		}
+	}
+
+	if rule.Interval < 1 {
+		rule.Interval = 1
+	}
+
+	switch rule.Freq {
+	case "MINUTELY", "HOURLY", "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return nil, fmt.Errorf("unsupported rule frequency: %s", rule.Freq)
+	}
+
+	return &rule, nil
+}
+
+// expand returns the start date and the rule occurrences
+// until the count, until date or the limit is reached
+func (r *Rule) expand(start time.Time, limit int) []time.Time {
+	if r.Count < 0 {
+		return nil
+	}
+
+	if r.Count > 0 && r.Count < limit {
+		limit = r.Count
+	}
+
+	dates := []time.Time{start}
+	for period := 0; period < maxPeriods && len(dates) < limit; period++ {
+		candidates := r.applySetPos(r.period(start, period))
+		for _, date := range candidates {
+			if !date.After(start) {
+				continue
+			}
+
+			if !r.Until.IsZero() && date.After(r.Until) {
+				return dates
+			}
+
+			dates = append(dates, date)
+			if len(dates) == limit {
+				break
+			}
+		}
+	}
+
+	return dates
+}
+
+// period returns the sorted candidate dates of the n-th rule period
+func (r *Rule) period(start time.Time, n int) []time.Time {
+	step := n * r.Interval
+	y, m, d := start.Date()
+	loc := start.Location()
+
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), 0, loc)
+	}
+
+	var candidates []time.Time
+	switch r.Freq {
+	case "MINUTELY", "HOURLY":
+		unit := time.Minute
+		if r.Freq == "HOURLY" {
+			unit = time.Hour
+		}
+		candidates = r.limit([]time.Time{start.Add(time.Duration(step) * unit)})
+	case "DAILY":
+		candidates = r.limit([]time.Time{at(y, m, d+step)})
+	case "WEEKLY":
+		offset := (int(start.Weekday()) - int(r.Wkst) + 7) % 7
+		weekStart := at(y, m, d-offset+7*step)
+		days := []time.Weekday{start.Weekday()}
+		if len(r.ByDay) > 0 {
+			days = days[:0]
+			for _, wd := range r.ByDay {
+				days = append(days, wd.Day)
+			}
+		}
+
+		for i := 0; i < 7; i++ {
+			date := weekStart.AddDate(0, 0, i)
+			for _, wd := range days {
+				if date.Weekday() == wd {
+					candidates = append(candidates, date)
+					break
+				}
+			}
+		}
+	case "MONTHLY":
+		first := at(y, m+time.Month(step), 1)
+		candidates = r.monthDays(first, d)
+	case "YEARLY":
+		year := y + step
+		switch {
+		case len(r.ByMonthDay) > 0:
+			for month := time.January; month <= time.December; month++ {
+				candidates = append(candidates, r.monthDays(at(year, month, 1), d)...)
+			}
+		case len(r.ByDay) > 0:
+			candidates = r.weekdaysIn(at(year, time.January, 1), at(year+1, time.January, 1))
+		default:
+			if date := at(year, m, d); date.Day() == d {
+				candidates = []time.Time{date}
+			}
+		}
+	}
+
+	return candidates
+}
+
+// monthDays returns the candidates of the month starting at `first`
+func (r *Rule) monthDays(first time.Time, startDay int) []time.Time {
+	next := first.AddDate(0, 1, 0)
+	daysInMonth := next.AddDate(0, 0, -1).Day()
+
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		if startDay > daysInMonth {
+			return nil
+		}
+		return []time.Time{first.AddDate(0, 0, startDay-1)}
+	}
+
+	var candidates []time.Time
+	if len(r.ByDay) > 0 {
+		candidates = r.weekdaysIn(first, next)
+	} else {
+		for day := 1; day <= daysInMonth; day++ {
+			candidates = append(candidates, first.AddDate(0, 0, day-1))
+		}
+	}
+
+	if len(r.ByMonthDay) == 0 {
+		return candidates
+	}
+
+	filtered := make([]time.Time, 0, len(candidates))
+	for _, date := range candidates {
+		if r.matchesMonthDay(date, daysInMonth) {
+			filtered = append(filtered, date)
+		}
+	}
+
+	return filtered
+}
+
+// weekdaysIn returns the BYDAY matches in the [from, to) range.
+// Ordinals count the weekday occurrences within the range
+func (r *Rule) weekdaysIn(from, to time.Time) []time.Time {
+	byWeekday := make(map[time.Weekday][]time.Time, 7)
+	for date := from; date.Before(to); date = date.AddDate(0, 0, 1) {
+		byWeekday[date.Weekday()] = append(byWeekday[date.Weekday()], date)
+	}
+
+	selected := make(map[int64]time.Time)
+	for _, wd := range r.ByDay {
+		matches := byWeekday[wd.Day]
+		switch {
+		case wd.N == 0:
+			for _, date := range matches {
+				selected[date.Unix()] = date
+			}
+		case wd.N > 0 && wd.N <= len(matches):
+			date := matches[wd.N-1]
+			selected[date.Unix()] = date
+		case wd.N < 0 && -wd.N <= len(matches):
+			date := matches[len(matches)+wd.N]
+			selected[date.Unix()] = date
+		}
+	}
+
+	return sortedDates(selected)
+}
+
+// limit filters the candidates of the sub-weekly frequencies by BYDAY and BYMONTHDAY
+func (r *Rule) limit(candidates []time.Time) []time.Time {
+	filtered := make([]time.Time, 0, len(candidates))
+	for _, date := range candidates {
+		daysInMonth := time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		if len(r.ByMonthDay) > 0 && !r.matchesMonthDay(date, daysInMonth) {
+			continue
+		}
+
+		if len(r.ByDay) > 0 && !r.matchesWeekday(date) {
+			continue
+		}
+
+		filtered = append(filtered, date)
+	}
+
+	return filtered
+}
+
+func (r *Rule) matchesMonthDay(date time.Time, daysInMonth int) bool {
+	for _, day := range r.ByMonthDay {
+		if day == date.Day() || (day < 0 && daysInMonth+day+1 == date.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesWeekday(date time.Time) bool {
+	for _, wd := range r.ByDay {
+		if wd.Day == date.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// applySetPos selects the BYSETPOS positions of the period candidates
+func (r *Rule) applySetPos(candidates []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 {
+		return candidates
+	}
+
+	selected := make(map[int64]time.Time, len(r.BySetPos))
+	for _, pos := range r.BySetPos {
+		switch {
+		case pos > 0 && pos <= len(candidates):
+			selected[candidates[pos-1].Unix()] = candidates[pos-1]
+		case pos < 0 && -pos <= len(candidates):
+			selected[candidates[len(candidates)+pos].Unix()] = candidates[len(candidates)+pos]
+		}
+	}
+
+	return sortedDates(selected)
+}
+
+func parseByDay(value string) ([]Weekday, error) {
+	days := make([]Weekday, 0)
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid weekday: %s", item)
+		}
+
+		wd, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday: %s", item)
+		}
+
+		n := 0
+		if ordinal := strings.TrimPrefix(item[:len(item)-2], "+"); ordinal != "" {
+			var err error
+			if n, err = strconv.Atoi(ordinal); err != nil {
+				return nil, err
+			}
+		}
+
+		days = append(days, Weekday{N: n, Day: wd})
+	}
+
+	return days, nil
+}
+
+func parseInts(value string) ([]int, error) {
+	ints := make([]int, 0)
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimPrefix(item, "+"))
+		if err != nil {
+			return nil, err
+		}
+		ints = append(ints, n)
+	}
+	return ints, nil
+}
+
+// parseDates parses comma separated UTC date times, floating date times
+// or dates. Floating values are interpreted in the given location
+func parseDates(value string, loc *time.Location) ([]time.Time, error) {
+	dates := make([]time.Time, 0)
+	for _, item := range strings.Split(value, ",") {
+		var (
+			date time.Time
+			err  error
+		)
+
+		switch {
+		case strings.HasSuffix(item, "Z"):
+			date, err = time.Parse(dateTimeFormat, item)
+		case len(item) == len(dateFormat):
+			date, err = time.ParseInLocation(dateFormat, item, loc)
+		default:
+			date, err = time.ParseInLocation(strings.TrimSuffix(dateTimeFormat, "Z"), item, loc)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid date: %s", item)
+		}
+		dates = append(dates, date)
+	}
+
+	return dates, nil
+}
+
+func sortedDates(set map[int64]time.Time) []time.Time {
+	dates := make([]time.Time, 0, len(set))
+	for _, date := range set {
+		dates = append(dates, date)
+	}
+
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	return dates
+}
@@ -0,0 +1,156 @@
+package rrule
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOccurrences(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const layout = "2006-01-02 15:04 MST"
+
+	tests := []struct {
+		name  string
+		start time.Time
+		lines []string
+		limit int
+		want  []string
+	}{
+		{
+			name:  "count includes the excluded dates",
+			start: time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC),
+			lines: []string{"RRULE:FREQ=WEEKLY;COUNT=4", "EXDATE:20261116T090000Z"},
+			limit: 10,
+			want:  []string{"2026-11-02 09:00 UTC", "2026-11-09 09:00 UTC", "2026-11-23 09:00 UTC"},
+		},
+		{
+			name:  "excluded start date",
+			start: time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC),
+			lines: []string{"RRULE:FREQ=DAILY;COUNT=3", "EXDATE:20261102T090000Z"},
+			limit: 10,
+			want:  []string{"2026-11-03 09:00 UTC", "2026-11-04 09:00 UTC"},
+		},
+		{
+			name:  "last friday of the month",
+			start: time.Date(2026, 10, 30, 9, 0, 0, 0, time.UTC),
+			lines: []string{"RRULE:FREQ=MONTHLY;BYDAY=-1FR"},
+			limit: 4,
+			want:  []string{"2026-10-30 09:00 UTC", "2026-11-27 09:00 UTC", "2026-12-25 09:00 UTC", "2027-01-29 09:00 UTC"},
+		},
+		{
+			name:  "set position of the weekdays",
+			start: time.Date(1997, 9, 4, 9, 0, 0, 0, newYork),
+			lines: []string{"RRULE:FREQ=MONTHLY;COUNT=3;BYDAY=TU,WE,TH;BYSETPOS=3"},
+			limit: 10,
+			want:  []string{"1997-09-04 09:00 EDT", "1997-10-07 09:00 EDT", "1997-11-06 09:00 EST"},
+		},
+		{
+			name:  "last work day of the month",
+			start: time.Date(1997, 9, 30, 9, 0, 0, 0, newYork),
+			lines: []string{"RRULE:FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1"},
+			limit: 4,
+			want:  []string{"1997-09-30 09:00 EDT", "1997-10-31 09:00 EST", "1997-11-28 09:00 EST", "1997-12-31 09:00 EST"},
+		},
+		{
+			name:  "biweekly with monday week start",
+			start: time.Date(1997, 8, 5, 9, 0, 0, 0, newYork),
+			lines: []string{"RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=MO"},
+			limit: 10,
+			want:  []string{"1997-08-05 09:00 EDT", "1997-08-10 09:00 EDT", "1997-08-19 09:00 EDT", "1997-08-24 09:00 EDT"},
+		},
+		{
+			name:  "biweekly with sunday week start",
+			start: time.Date(1997, 8, 5, 9, 0, 0, 0, newYork),
+			lines: []string{"RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU"},
+			limit: 10,
+			want:  []string{"1997-08-05 09:00 EDT", "1997-08-17 09:00 EDT", "1997-08-19 09:00 EDT", "1997-08-31 09:00 EDT"},
+		},
+		{
+			name:  "yearly on february 29",
+			start: time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC),
+			lines: []string{"RRULE:FREQ=YEARLY;COUNT=3"},
+			limit: 10,
+			want:  []string{"2024-02-29 09:00 UTC", "2028-02-29 09:00 UTC", "2032-02-29 09:00 UTC"},
+		},
+		{
+			name:  "until is inclusive",
+			start: time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC),
+			lines: []string{"RRULE:FREQ=WEEKLY;UNTIL=20261116T090000Z"},
+			limit: 10,
+			want:  []string{"2026-11-02 09:00 UTC", "2026-11-09 09:00 UTC", "2026-11-16 09:00 UTC"},
+		},
+		{
+			name:  "until before the next occurrence",
+			start: time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC),
+			lines: []string{"RRULE:FREQ=WEEKLY;UNTIL=20261116T085959Z"},
+			limit: 10,
+			want:  []string{"2026-11-02 09:00 UTC", "2026-11-09 09:00 UTC"},
+		},
+		{
+			name:  "all-day set",
+			start: time.Date(2026, 11, 2, 0, 0, 0, 0, berlin),
+			lines: []string{"RRULE:FREQ=WEEKLY;UNTIL=20261123", "EXDATE;VALUE=DATE:20261109", "RDATE;VALUE=DATE:20261111"},
+			limit: 10,
+			want:  []string{"2026-11-02 00:00 CET", "2026-11-11 00:00 CET", "2026-11-16 00:00 CET", "2026-11-23 00:00 CET"},
+		},
+		{
+			name:  "wall clock across the dst transition",
+			start: time.Date(2026, 10, 19, 9, 0, 0, 0, berlin),
+			lines: []string{"RRULE:FREQ=WEEKLY;COUNT=3"},
+			limit: 10,
+			want:  []string{"2026-10-19 09:00 CEST", "2026-10-26 09:00 CET", "2026-11-02 09:00 CET"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := Parse(tt.start, tt.lines)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]string, 0)
+			for _, date := range set.Occurrences(tt.limit) {
+				got = append(got, date.Format(layout))
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	start := time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"missing value", "RRULE"},
+		{"unsupported frequency", "RRULE:FREQ=SECONDLY"},
+		{"unsupported part", "RRULE:FREQ=YEARLY;BYMONTH=12"},
+		{"invalid weekday", "RRULE:FREQ=WEEKLY;BYDAY=XX"},
+		{"invalid date", "EXDATE:2026-11-02"},
+		{"unsupported line", "EXRULE:FREQ=WEEKLY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(start, []string{tt.line}); err == nil {
+				t.Errorf("expected an error for %s", tt.line)
+			}
+		})
+	}
+}
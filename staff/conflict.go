@@ -0,0 +1,296 @@
+package staff
+
+import (
+	"time"
+
+	"github.com/makarski/gcaler/config"
+	"github.com/makarski/gcaler/rrule"
+)
+
+// SeriesPreview is the number of the first recurring series occurrences
+// previewed and checked for conflicts before the series are inserted
+const SeriesPreview = 10
+
+type (
+	// Period is a time range with an exclusive end
+	Period struct {
+		Start time.Time
+		End   time.Time
+	}
+
+	// Busy describes the busy periods of the queried calendars
+	Busy struct {
+		// Periods maps the calendar emails to their busy periods
+		Periods map[string][]Period
+		// Unreadable maps the calendars which could not be queried to the reason
+		Unreadable map[string]string
+	}
+
+	// BusyFunc returns the busy periods of the assignees in the given time range
+	BusyFunc func(emails []string, start, end time.Time) (Busy, error)
+
+	// RoomConflict describes a room booked during a planned event,
+	// or a room calendar which could not be checked
+	RoomConflict struct {
+		Room string
+		Period
+		// Unreadable is the reason the room calendar could not be checked
+		Unreadable string
+	}
+
+	// Conflict describes an assignee being busy during the planned shift,
+	// or an assignee calendar which could not be checked
+	Conflict struct {
+		Date        time.Time
+		Assignee    *config.Assignee
+		Replacement *config.Assignee
+		Skipped     bool
+		// Unreadable is the reason the assignee calendar could not be checked
+		Unreadable string
+	}
+)
+
+// IsBusy checks whether the assignee has a busy period overlapping the range
+func (b Busy) IsBusy(email string, start, end time.Time) bool {
+	for _, period := range b.Periods[email] {
+		if period.Start.Before(end) && period.End.After(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// busyDuring returns the periods during which the assignee is busy
+func (b Busy) busyDuring(email string, periods []Period) []Period {
+	busy := make([]Period, 0)
+	for _, period := range periods {
+		if b.IsBusy(email, period.Start, period.End) {
+			busy = append(busy, period)
+		}
+	}
+	return busy
+}
+
+// Periods returns the event periods of the assignment: the shift,
+// or the first SeriesPreview occurrences of a recurring series
+func (a Assignment) Periods(template *config.Template) ([]Period, error) {
+	dates := []time.Time{a.Date}
+
+	if template.Recurrence.Mode.IsSeries() {
+		rules, err := a.SeriesOf(template).RFC5545(a.Date)
+		if err != nil {
+			return nil, err
+		}
+
+		set, err := rrule.Parse(a.Date, rules)
+		if err != nil {
+			return nil, err
+		}
+
+		dates = set.Occurrences(SeriesPreview)
+	}
+
+	periods := make([]Period, 0, len(dates))
+	for _, date := range dates {
+		periods = append(periods, Period{Start: date, End: date.Add(template.Duration)})
+	}
+
+	return periods, nil
+}
+
+// RoomConflicts returns the rooms booked during the planned periods.
+// The rooms are queried once over the span of the periods,
+// rooms which could not be read are reported and not checked
+func RoomConflicts(rooms []string, periods []Period, busyFn BusyFunc) ([]RoomConflict, error) {
+	conflicts := make([]RoomConflict, 0)
+	if len(rooms) == 0 || len(periods) == 0 {
+		return conflicts, nil
+	}
+
+	all := span(periods)
+	busy, err := busyFn(rooms, all.Start, all.End)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, room := range rooms {
+		if reason, ok := busy.Unreadable[room]; ok {
+			conflicts = append(conflicts, RoomConflict{Room: room, Period: all, Unreadable: reason})
+			continue
+		}
+
+		for _, period := range busy.busyDuring(room, periods) {
+			conflicts = append(conflicts, RoomConflict{Room: room, Period: period})
+		}
+	}
+
+	return conflicts, nil
+}
+
+// span returns the period from the earliest start to the latest end of the periods
+func span(periods []Period) Period {
+	all := periods[0]
+	for _, period := range periods[1:] {
+		if period.Start.Before(all.Start) {
+			all.Start = period.Start
+		}
+		if period.End.After(all.End) {
+			all.End = period.End
+		}
+	}
+	return all
+}
+
+// ResolveConflicts checks the assignees' availability for every assignment
+// and applies the conflict policy: conflicting assignments are either kept,
+// skipped or reassigned to the next free participant.
+// Recurring series are checked at their first SeriesPreview occurrences:
+// `skip` excludes the conflicting occurrences from the series and `reassign`
+// replaces the assignee for the whole series with a participant free at all of them.
+// The calendars are queried once per assignment over the span of its periods,
+// calendars which could not be read are reported once and not checked
+func (a Assignees) ResolveConflicts(
+	assignments []Assignment,
+	template *config.Template,
+	busyFn BusyFunc,
+) ([]Assignment, []Conflict, error) {
+	emails := make([]string, 0, len(a))
+	for _, person := range a {
+		emails = append(emails, person.Email)
+	}
+
+	policy := template.OnConflict
+	isSeries := template.Recurrence.Mode.IsSeries()
+
+	resolved := make([]Assignment, 0, len(assignments))
+	conflicts := make([]Conflict, 0)
+	unreadable := make(map[string]bool)
+
+	for _, assignment := range assignments {
+		periods, err := assignment.Periods(template)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		all := span(periods)
+		busy, err := busyFn(emails, all.Start, all.End)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		skip := false
+		excluded := make([]time.Time, 0)
+		assignees := make(Assignees, 0, len(assignment.Assignees))
+
+		for _, person := range assignment.Assignees {
+			if reason, ok := busy.Unreadable[person.Email]; ok && !unreadable[person.Email] {
+				unreadable[person.Email] = true
+				conflicts = append(conflicts, Conflict{Date: assignment.Date, Assignee: person, Unreadable: reason})
+			}
+
+			conflictsAt := busy.busyDuring(person.Email, periods)
+			if len(conflictsAt) == 0 {
+				assignees = append(assignees, person)
+				continue
+			}
+
+			var replacement *config.Assignee
+			if policy.IsReassign() {
+				taken := append(append(Assignees{}, assignment.Assignees...), assignees...)
+				replacement = a.replacement(person, taken, func(p *config.Assignee) bool {
+					if _, ok := busy.Unreadable[p.Email]; ok {
+						return false
+					}
+
+					for _, period := range periods {
+						if !p.IsAvailable(period.Start, period.End.Sub(period.Start)) {
+							return false
+						}
+					}
+					return len(busy.busyDuring(p.Email, periods)) == 0
+				})
+			}
+
+			for _, period := range conflictsAt {
+				conflicts = append(conflicts, Conflict{
+					Date:        period.Start,
+					Assignee:    person,
+					Replacement: replacement,
+					Skipped:     policy.IsSkip(),
+				})
+
+				if policy.IsSkip() {
+					excluded = append(excluded, period.Start)
+				}
+			}
+
+			if policy.IsSkip() && !isSeries {
+				skip = true
+			}
+
+			if replacement != nil {
+				assignees = append(assignees, replacement)
+			} else {
+				assignees = append(assignees, person)
+			}
+		}
+
+		if skip {
+			continue
+		}
+
+		assignment.Assignees = assignees
+		if isSeries && len(excluded) > 0 {
+			assignment.Recurrence = excludeDates(assignment.SeriesOf(template), excluded)
+		}
+
+		resolved = append(resolved, assignment)
+	}
+
+	return resolved, conflicts, nil
+}
+
+// excludeDates returns a copy of the recurrence excluding the dates
+func excludeDates(recurrence *config.Recurrence, dates []time.Time) *config.Recurrence {
+	series := *recurrence
+	series.ExDates = append([]string{}, recurrence.ExDates...)
+	for _, date := range dates {
+		series.ExDates = append(series.ExDates, date.Format(time.RFC3339))
+	}
+	return &series
+}
+
+// replacement returns the first participant following `person`
+// in the rotation order who is not assigned yet and is available
+func (a Assignees) replacement(
+	person *config.Assignee,
+	assigned Assignees,
+	isAvailable func(*config.Assignee) bool,
+) *config.Assignee {
+	start := 0
+	for i, p := range a {
+		if p.Email == person.Email {
+			start = i + 1
+			break
+		}
+	}
+
+	for i := 0; i < len(a); i++ {
+		candidate := a[(start+i)%len(a)]
+		if assigned.contains(candidate) || !isAvailable(candidate) {
+			continue
+		}
+		return candidate
+	}
+
+	return nil
+}
+
+func (a Assignees) contains(person *config.Assignee) bool {
+	for _, p := range a {
+		if p.Email == person.Email {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package staff
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/makarski/gcaler/config"
+)
+
+func TestResolveConflicts(t *testing.T) {
+	alice := &config.Assignee{FirstName: "Alice", Email: "alice@example.com"}
+	bob := &config.Assignee{FirstName: "Bob", Email: "bob@example.com"}
+	carol := &config.Assignee{FirstName: "Carol", Email: "carol@example.com"}
+	participants := Assignees{alice, bob, carol}
+
+	start := time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC)
+	week := 7 * 24 * time.Hour
+
+	busyFn := func(busy map[string][]Period, unreadable map[string]string) BusyFunc {
+		return func(emails []string, start, end time.Time) (Busy, error) {
+			return Busy{Periods: busy, Unreadable: unreadable}, nil
+		}
+	}
+
+	// alice is busy during the third weekly occurrence
+	thirdWeek := map[string][]Period{
+		alice.Email: {{Start: start.Add(2 * week), End: start.Add(2*week + time.Hour)}},
+	}
+
+	tests := []struct {
+		name          string
+		policy        config.ConflictPolicy
+		busyFn        BusyFunc
+		wantAssignees []string
+		wantExDates   []string
+		wantConflicts int
+	}{
+		{
+			name:          "warn keeps the series",
+			policy:        config.ConflictWarn,
+			busyFn:        busyFn(thirdWeek, nil),
+			wantAssignees: []string{alice.Email},
+			wantConflicts: 1,
+		},
+		{
+			name:          "skip excludes the conflicting occurrence",
+			policy:        config.ConflictSkip,
+			busyFn:        busyFn(thirdWeek, nil),
+			wantAssignees: []string{alice.Email},
+			wantExDates:   []string{"2026-11-16T09:00:00Z"},
+			wantConflicts: 1,
+		},
+		{
+			name:          "reassign replaces the assignee of the series",
+			policy:        config.ConflictReassign,
+			busyFn:        busyFn(thirdWeek, nil),
+			wantAssignees: []string{bob.Email},
+			wantConflicts: 1,
+		},
+		{
+			name:   "reassign skips participants busy at any occurrence",
+			policy: config.ConflictReassign,
+			busyFn: busyFn(map[string][]Period{
+				alice.Email: thirdWeek[alice.Email],
+				bob.Email:   {{Start: start.Add(5 * week), End: start.Add(5*week + time.Hour)}},
+			}, nil),
+			wantAssignees: []string{carol.Email},
+			wantConflicts: 1,
+		},
+		{
+			name:          "unreadable calendars are reported",
+			policy:        config.ConflictReassign,
+			busyFn:        busyFn(nil, map[string]string{alice.Email: "notFound"}),
+			wantAssignees: []string{alice.Email},
+			wantConflicts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := &config.Template{
+				Duration:   8 * time.Hour,
+				OnConflict: tt.policy,
+				Recurrence: config.Recurrence{
+					Mode:     config.RecModeRecurrent,
+					Freq:     config.FreqWeekly,
+					Interval: 1,
+					Count:    -1,
+				},
+			}
+
+			assignments := []Assignment{{Assignees: Assignees{alice}, Date: start}}
+
+			calls := 0
+			countedFn := func(emails []string, from, to time.Time) (Busy, error) {
+				calls++
+				return tt.busyFn(emails, from, to)
+			}
+
+			resolved, conflicts, err := participants.ResolveConflicts(assignments, template, countedFn)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if calls != 1 {
+				t.Errorf("got %d freebusy queries, want 1 per assignment", calls)
+			}
+
+			if len(resolved) != 1 {
+				t.Fatalf("resolved: got %d assignments, want 1", len(resolved))
+			}
+
+			emails := make([]string, 0)
+			for _, person := range resolved[0].Assignees {
+				emails = append(emails, person.Email)
+			}
+
+			if !reflect.DeepEqual(emails, tt.wantAssignees) {
+				t.Errorf("assignees: got %v, want %v", emails, tt.wantAssignees)
+			}
+
+			var exDates []string
+			if resolved[0].Recurrence != nil {
+				exDates = resolved[0].Recurrence.ExDates
+			}
+
+			if !reflect.DeepEqual(exDates, tt.wantExDates) {
+				t.Errorf("exdates: got %v, want %v", exDates, tt.wantExDates)
+			}
+
+			if len(conflicts) != tt.wantConflicts {
+				t.Errorf("conflicts: got %d, want %d", len(conflicts), tt.wantConflicts)
+			}
+		})
+	}
+}
+
+func TestResolveConflictsSkipsSingleEvents(t *testing.T) {
+	alice := &config.Assignee{FirstName: "Alice", Email: "alice@example.com"}
+	bob := &config.Assignee{FirstName: "Bob", Email: "bob@example.com"}
+
+	start := time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC)
+	template := &config.Template{
+		Duration:   8 * time.Hour,
+		OnConflict: config.ConflictSkip,
+		Recurrence: config.Recurrence{Mode: config.RecModeSingle},
+	}
+
+	assignments := []Assignment{
+		{Assignees: Assignees{alice}, Date: start},
+		{Assignees: Assignees{bob}, Date: start.AddDate(0, 0, 1)},
+	}
+
+	busyFn := func(emails []string, from, to time.Time) (Busy, error) {
+		return Busy{Periods: map[string][]Period{
+			alice.Email: {{Start: start, End: start.Add(time.Hour)}},
+		}}, nil
+	}
+
+	resolved, conflicts, err := Assignees{alice, bob}.ResolveConflicts(assignments, template, busyFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resolved) != 1 || resolved[0].Assignees[0] != bob {
+		t.Errorf("resolved: got %v, want bob's shift only", resolved)
+	}
+
+	if len(conflicts) != 1 || !conflicts[0].Skipped {
+		t.Errorf("conflicts: got %v, want one skipped", conflicts)
+	}
+}
+
+func TestRoomConflicts(t *testing.T) {
+	start := time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC)
+	periods := []Period{
+		{Start: start, End: start.Add(time.Hour)},
+		{Start: start.AddDate(0, 0, 7), End: start.AddDate(0, 0, 7).Add(time.Hour)},
+		{Start: start.AddDate(0, 0, 14), End: start.AddDate(0, 0, 14).Add(time.Hour)},
+	}
+
+	calls := 0
+	busyFn := func(emails []string, from, to time.Time) (Busy, error) {
+		calls++
+		if !from.Equal(periods[0].Start) || !to.Equal(periods[2].End) {
+			t.Errorf("query range: got %s - %s, want the span of the periods", from, to)
+		}
+
+		return Busy{
+			Periods: map[string][]Period{
+				"big@example.com": {{Start: start.AddDate(0, 0, 7), End: start.AddDate(0, 0, 7).Add(30 * time.Minute)}},
+			},
+			Unreadable: map[string]string{"private@example.com": "notFound"},
+		}, nil
+	}
+
+	conflicts, err := RoomConflicts([]string{"big@example.com", "small@example.com", "private@example.com"}, periods, busyFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 1 {
+		t.Errorf("got %d freebusy queries, want 1", calls)
+	}
+
+	want := []RoomConflict{
+		{Room: "big@example.com", Period: periods[1]},
+		{Room: "private@example.com", Period: Period{Start: periods[0].Start, End: periods[2].End}, Unreadable: "notFound"},
+	}
+
+	if !reflect.DeepEqual(conflicts, want) {
+		t.Errorf("got %v, want %v", conflicts, want)
+	}
+}
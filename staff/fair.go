@@ -0,0 +1,148 @@
+package staff
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+type (
+	// Load counts the shifts assigned to a person
+	Load struct {
+		Shifts   int
+		Weekends int
+		Holidays int
+
+		lastDate time.Time
+	}
+
+	// Tally maps assignee emails to their shift load
+	Tally map[string]*Load
+)
+
+// NewTally counts the shifts of the assignees in the past assignments
+func NewTally(a Assignees, history []Assignment, isHoliday func(time.Time) bool) Tally {
+	tally := make(Tally, len(a))
+	for _, person := range a {
+		tally[person.Email] = &Load{}
+	}
+
+	for _, assignment := range history {
+		tally.add(assignment, isHoliday)
+	}
+
+	return tally
+}
+
+// With returns a copy of the tally including the assignments
+func (t Tally) With(assignments []Assignment, isHoliday func(time.Time) bool) Tally {
+	tally := make(Tally, len(t))
+	for email, load := range t {
+		l := *load
+		tally[email] = &l
+	}
+
+	for _, assignment := range assignments {
+		tally.add(assignment, isHoliday)
+	}
+
+	return tally
+}
+
+// Print writes the per person totals in the order of the assignees
+func (t Tally) Print(w io.Writer, a Assignees) {
+	fmt.Fprintf(w, "  %-30s %8s %8s %8s\n", "", "shifts", "weekends", "holidays")
+	for _, person := range a {
+		load, ok := t[person.Email]
+		if !ok {
+			continue
+		}
+
+		fmt.Fprintf(
+			w,
+			"  %-30s %8d %8d %8d\n",
+			person.FullName(),
+			load.Shifts,
+			load.Weekends,
+			load.Holidays,
+		)
+	}
+}
+
+func (t Tally) add(assignment Assignment, isHoliday func(time.Time) bool) {
+	for _, person := range assignment.Assignees {
+		load, ok := t[person.Email]
+		if !ok {
+			continue
+		}
+
+		load.Shifts++
+		if isWeekend(assignment.Date) {
+			load.Weekends++
+		}
+		if isHoliday(assignment.Date) {
+			load.Holidays++
+		}
+		if assignment.Date.After(load.lastDate) {
+			load.lastDate = assignment.Date
+		}
+	}
+}
+
+// assignFair assigns every date to the `groupSize` participants
+// with the lowest load, giving weekend and holiday dates
+// to the people with the fewest weekend and holiday shifts
+func (a Assignees) assignFair(
+	dates <-chan time.Time,
+	duration time.Duration,
+	groupSize int,
+	tally Tally,
+	isHoliday func(time.Time) bool,
+) ([]Assignment, error) {
+	tally = tally.With(nil, isHoliday)
+	for _, person := range a {
+		if _, ok := tally[person.Email]; !ok {
+			tally[person.Email] = &Load{}
+		}
+	}
+
+	assignments := make([]Assignment, 0)
+
+	for date := range dates {
+		candidates := make(Assignees, 0, len(a))
+		for _, person := range a {
+			if person.IsAvailable(date, duration) {
+				candidates = append(candidates, person)
+			}
+		}
+
+		if len(candidates) < groupSize {
+			return nil, fmt.Errorf("not enough available assignees on %s", date.Format("2006-01-02"))
+		}
+
+		weekend, holiday := isWeekend(date), isHoliday(date)
+		sort.SliceStable(candidates, func(i, j int) bool {
+			li, lj := tally[candidates[i].Email], tally[candidates[j].Email]
+			switch {
+			case holiday && li.Holidays != lj.Holidays:
+				return li.Holidays < lj.Holidays
+			case weekend && li.Weekends != lj.Weekends:
+				return li.Weekends < lj.Weekends
+			case li.Shifts != lj.Shifts:
+				return li.Shifts < lj.Shifts
+			}
+			return li.lastDate.Before(lj.lastDate)
+		})
+
+		assignment := Assignment{Date: date, Assignees: candidates[:groupSize]}
+		tally.add(assignment, isHoliday)
+		assignments = append(assignments, assignment)
+	}
+
+	return assignments, nil
+}
+
+func isWeekend(date time.Time) bool {
+	return date.Weekday() == time.Saturday || date.Weekday() == time.Sunday
+}
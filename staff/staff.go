@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/makarski/gcaler/config"
+	"github.com/makarski/gcaler/cursor"
 	"github.com/makarski/gcaler/planweek"
 	"github.com/makarski/gcaler/userio"
 )
@@ -22,33 +23,134 @@ type (
 	Assignment struct {
 		Assignees
 		Date time.Time
+		// Recurrence overrides the template recurrence of the event
+		Recurrence *config.Recurrence
+
+		// Index is the position of the assignment in the plan,
+		// Previous and Next are the neighbouring assignees
+		Index    int
+		Previous Assignees
+		Next     Assignees
+	}
+
+	// Input replaces the interactive schedule prompts
+	Input struct {
+		// Start is the first event date, prompted if nil
+		Start *time.Time
+		// Picks are the assignees of the manual rotation per planned date:
+		// participant indexes or emails, prompted if empty
+		Picks [][]string
+	}
+
+	// History describes the shifts planned before
+	History struct {
+		// Last is the persisted rotation cursor, nil if none
+		Last *cursor.Cursor
+		// Tally counts the past shifts per assignee
+		Tally Tally
 	}
 )
 
 func (a Assignees) pick(i int) (*config.Assignee, error) {
-	if i > len(a)-1 {
+	if i < 0 || i > len(a)-1 {
 		return nil, fmt.Errorf("no assignee found by index: %d", i)
 	}
 	return a[i], nil
 }
 
+// pickRef picks an assignee by index or email
+func (a Assignees) pickRef(ref string) (*config.Assignee, error) {
+	if i, err := strconv.Atoi(ref); err == nil {
+		return a.pick(i)
+	}
+
+	for _, person := range a {
+		if strings.EqualFold(person.Email, ref) {
+			return person, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no assignee found by email: %s", ref)
+}
+
+// Names returns the comma separated full names of the assignees
+func (a Assignees) Names() string {
+	names := make([]string, 0, len(a))
+	for _, person := range a {
+		names = append(names, person.FullName())
+	}
+	return strings.Join(names, ", ")
+}
+
 func (a Assignees) print(w io.Writer) {
 	for i, person := range a {
 		fmt.Fprintf(w, "  * %d: %s\n", i, person.FullName())
 	}
 }
 
-func (a Assignees) startDate(timezone *time.Location) (*time.Time, error) {
-	startDate, err := userio.UserIn(bytes.NewBufferString("> Enter event date (ex: 2006-10-22): "))
+// resumeIndex returns the index of the participant
+// following the last assigned one in the rotation
+func (a Assignees) resumeIndex(last *cursor.Cursor) int {
+	if last == nil || len(last.LastAssignees) == 0 {
+		return 0
+	}
+
+	lastEmail := last.LastAssignees[len(last.LastAssignees)-1]
+	for i, person := range a {
+		if person.Email == lastEmail {
+			return (i + 1) % len(a)
+		}
+	}
+
+	return 0
+}
+
+// startDate prompts for the first event date.
+// The date following the last planned shift is proposed as default
+func (a Assignees) startDate(
+	timezone *time.Location,
+	last *cursor.Cursor,
+	frequency planweek.Step,
+) (*time.Time, error) {
+	var dateCta, timeCta bytes.Buffer
+
+	var proposed *time.Time
+	if last != nil {
+		next := frequency.Nth(last.LastDate.In(timezone), 1)
+		proposed = &next
+
+		a.printLastShift(&dateCta, last, timezone)
+	}
+
+	dateCta.WriteString("> Enter event date (ex: 2006-10-22)")
+	timeCta.WriteString("> Enter event time (ex: 15:04)")
+
+	if proposed != nil {
+		fmt.Fprintf(&dateCta, " [%s]", proposed.Format("2006-01-02"))
+		fmt.Fprintf(&timeCta, " [%s]", proposed.Format("15:04"))
+	}
+
+	dateCta.WriteString(": ")
+	timeCta.WriteString(": ")
+
+	startDate, err := userio.UserIn(&dateCta)
 	if err != nil {
 		return nil, err
 	}
 
-	startTime, err := userio.UserIn(bytes.NewBufferString("> Enter event time (ex: 15:04): "))
+	startTime, err := userio.UserIn(&timeCta)
 	if err != nil {
 		return nil, err
 	}
 
+	if proposed != nil && startDate == "" {
+		startDate = proposed.Format("2006-01-02")
+	}
+
+	if proposed != nil && startTime == "" {
+		startTime = proposed.Format("15:04")
+	}
+
 	date, err := time.ParseInLocation("2006-01-02 15:04", startDate+" "+startTime, timezone)
 	if err != nil {
 		return nil, err
@@ -58,35 +160,245 @@ func (a Assignees) startDate(timezone *time.Location) (*time.Time, error) {
 }
 
 // Schedule returns a slice of Assignment pairs: Assignee to Date
+// and the dates skipped as weekends, holidays or not planned weekdays.
+// The values missing in the input are prompted for
 func (a Assignees) Schedule(
 	ctx context.Context,
 	timezone *time.Location,
-	recurrence *config.Recurrence,
-) ([]Assignment, error) {
-	startDate, err := a.startDate(timezone)
-	if err != nil {
-		return nil, err
+	template *config.Template,
+	history History,
+	input Input,
+) ([]Assignment, []planweek.Skipped, error) {
+	recurrence := &template.Recurrence
+
+	startDate := input.Start
+	if startDate == nil {
+		var err error
+		startDate, err = a.startDate(timezone, history.Last, recurrence.Step())
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if template.AllDay {
+		year, month, day := startDate.In(timezone).Date()
+		midnight := time.Date(year, month, day, 0, 0, 0, 0, timezone)
+		startDate = &midnight
 	}
 
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	if recurrence.Mode.IsStaggered() {
+		assignments, err := a.stagger(*startDate, recurrence, template.GroupSize, a.resumeIndex(history.Last))
+		return assignments, nil, err
+	}
+
 	eventCount := recurrence.Count
 	if recurrence.Mode.IsRecurrent() {
 		eventCount = 1
 	}
 
-	dates, err := planweek.Plan(ctx, *startDate, eventCount, recurrence.Frequency)
+	skipped := make([]planweek.Skipped, 0)
+	dates, err := planweek.Plan(
+		ctx,
+		*startDate,
+		eventCount,
+		recurrence.Step(),
+		recurrence.DateFilter(),
+		func(s planweek.Skipped) { skipped = append(skipped, s) },
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var assignments []Assignment
+	switch {
+	case template.Rotation.IsRoundRobin():
+		assignments, err = a.assignRoundRobin(dates, template.Duration, template.GroupSize, a.resumeIndex(history.Last))
+	case template.Rotation.IsFair():
+		assignments, err = a.assignFair(dates, template.Duration, template.GroupSize, history.Tally, recurrence.IsHoliday)
+	default:
+		assignments, err = a.assignBatch(dates, template.Duration, input.Picks)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return assignments, skipped, nil
+}
+
+// Link sets the plan positions and the neighbouring assignees
+// of the assignments, used for the handover notes
+func Link(assignments []Assignment) {
+	for i := range assignments {
+		assignments[i].Index = i
+		assignments[i].Previous = nil
+		assignments[i].Next = nil
+
+		if i > 0 {
+			assignments[i].Previous = assignments[i-1].Assignees
+		}
+
+		if i < len(assignments)-1 {
+			assignments[i].Next = assignments[i+1].Assignees
+		}
+	}
+}
+
+// SeriesOf returns the recurrence of the assignment event
+// falling back to the template recurrence
+func (a Assignment) SeriesOf(template *config.Template) *config.Recurrence {
+	if a.Recurrence != nil {
+		return a.Recurrence
+	}
+	return &template.Recurrence
+}
+
+// stagger plans one recurring series per group of participants.
+// With `n` groups each series repeats every `n × interval` frequency units
+// and starts `interval` units after the previous group, so that
+// the combined series form the rotation
+func (a Assignees) stagger(
+	start time.Time,
+	recurrence *config.Recurrence,
+	groupSize int,
+	cursor int,
+) ([]Assignment, error) {
+	if len(a) == 0 {
+		return nil, fmt.Errorf("no assignees to rotate")
+	}
+
+	unit, err := recurrence.FrequencyStep()
 	if err != nil {
 		return nil, err
 	}
 
-	return a.assignBatch(dates)
+	interval := int(recurrence.Interval)
+	if interval == 0 {
+		interval = 1
+	}
+
+	groups := len(a) / groupSize
+	assignments := make([]Assignment, 0, groups)
+
+	for g := 0; g < groups; g++ {
+		series := *recurrence
+		series.Mode = config.RecModeRecurrent
+		series.Interval = uint32(groups * interval)
+
+		if recurrence.Count > 0 {
+			// shifts of the rotation taken by the group: ceil((count - g) / groups)
+			series.Count = (recurrence.Count - int32(g) + int32(groups) - 1) / int32(groups)
+			if series.Count <= 0 {
+				continue
+			}
+		}
+
+		assignees := make(Assignees, 0, groupSize)
+		for i := 0; i < groupSize; i++ {
+			assignees = append(assignees, a[(cursor+g*groupSize+i)%len(a)])
+		}
+
+		assignments = append(assignments, Assignment{
+			Assignees:  assignees,
+			Date:       unit.Nth(start, g*interval),
+			Recurrence: &series,
+		})
+	}
+
+	return assignments, nil
+}
+
+// Cursor returns the rotation state after the last planned assignment
+func Cursor(template string, assignments []Assignment) *cursor.Cursor {
+	if len(assignments) == 0 {
+		return nil
+	}
+
+	last := assignments[len(assignments)-1]
+	emails := make([]string, 0, len(last.Assignees))
+	for _, person := range last.Assignees {
+		emails = append(emails, person.Email)
+	}
+
+	return &cursor.Cursor{
+		Template:      template,
+		LastAssignees: emails,
+		LastDate:      last.Date,
+	}
+}
+
+func (a Assignees) printLastShift(w io.Writer, last *cursor.Cursor, timezone *time.Location) {
+	names := make([]string, 0, len(last.LastAssignees))
+	for _, email := range last.LastAssignees {
+		name := email
+		for _, person := range a {
+			if person.Email == email {
+				name = person.FullName()
+				break
+			}
+		}
+		names = append(names, name)
+	}
+
+	fmt.Fprintf(
+		w,
+		"> Last planned shift: %s on %s\n",
+		strings.Join(names, ", "),
+		last.LastDate.In(timezone).Format(time.RFC1123),
+	)
 }
 
-func (a Assignees) assignBatch(dates <-chan time.Time) ([]Assignment, error) {
+// assignRoundRobin assigns groups of `groupSize` participants
+// to the dates in the order they are listed in the template.
+// Participants unavailable during a shift are skipped for it
+func (a Assignees) assignRoundRobin(dates <-chan time.Time, duration time.Duration, groupSize int, cursor int) ([]Assignment, error) {
+	if len(a) == 0 {
+		return nil, fmt.Errorf("no assignees to rotate")
+	}
+
 	assignments := make([]Assignment, 0)
 
+	for date := range dates {
+		assignees := make(Assignees, 0, groupSize)
+		for i := 0; i < len(a) && len(assignees) < groupSize; i++ {
+			person := a[(cursor+i)%len(a)]
+			if !person.IsAvailable(date, duration) {
+				continue
+			}
+
+			assignees = append(assignees, person)
+			if len(assignees) == groupSize {
+				cursor = (cursor + i + 1) % len(a)
+			}
+		}
+
+		if len(assignees) < groupSize {
+			return nil, fmt.Errorf("not enough available assignees on %s", date.Format("2006-01-02"))
+		}
+
+		assignments = append(assignments, Assignment{Date: date, Assignees: assignees})
+	}
+
+	return assignments, nil
+}
+
+func (a Assignees) assignBatch(dates <-chan time.Time, duration time.Duration, picks [][]string) ([]Assignment, error) {
+	if len(picks) > 0 {
+		schedule := make([]time.Time, 0, len(picks))
+		for date := range dates {
+			schedule = append(schedule, date)
+		}
+
+		if len(picks) != len(schedule) {
+			return nil, fmt.Errorf("%d assignee picks given for %d planned dates", len(picks), len(schedule))
+		}
+
+		return a.assignPicks(schedule, duration, picks)
+	}
+
 	var pickCtaTxt bytes.Buffer
 	_, err := fmt.Fprintf(&pickCtaTxt, "> Available Assignees:\n")
 	if err != nil {
@@ -115,22 +427,41 @@ func (a Assignees) assignBatch(dates <-chan time.Time) ([]Assignment, error) {
 		inPicks = append(inPicks, strings.Split(in, " "))
 	}
 
+	return a.assignPicks(schedule, duration, inPicks)
+}
+
+// assignPicks assigns the picked participants, referenced by index or email, to the dates
+func (a Assignees) assignPicks(schedule []time.Time, duration time.Duration, inPicks [][]string) ([]Assignment, error) {
+	assignments := make([]Assignment, 0, len(schedule))
+
 	for i, inPick := range inPicks {
 		assignees := make([]*config.Assignee, 0, len(inPicks))
 		for _, pick := range inPick {
-			pickedIndex, err := strconv.Atoi(pick)
-			if err != nil {
-				return nil, err
+			if pick == "" {
+				continue
 			}
 
-			assignedPerson, err := a.pick(pickedIndex)
+			assignedPerson, err := a.pickRef(pick)
 			if err != nil {
 				return nil, err
 			}
 
+			if !assignedPerson.IsAvailable(schedule[i], duration) {
+				return nil, fmt.Errorf(
+					"%s is unavailable on %s",
+					assignedPerson.FullName(),
+					schedule[i].Format("2006-01-02"),
+				)
+			}
+
 			assignees = append(assignees, assignedPerson)
 
 		}
+
+		if len(assignees) == 0 {
+			return nil, fmt.Errorf("no assignee picked for %s", schedule[i].Format("2006-01-02"))
+		}
+
 		assignment := Assignment{Date: schedule[i], Assignees: assignees}
 		assignments = append(assignments, assignment)
 	}
@@ -15,7 +15,8 @@ const (
 
 var (
 	out = os.Stdout
-	in  = os.Stdin
+	// in is shared by the prompts, so that no buffered input is lost between them
+	in = bufio.NewReader(os.Stdin)
 
 	unexpectedNewlineErr = errors.New("unexpected newline")
 )
@@ -25,13 +26,12 @@ func UserIn(buf io.Reader) (string, error) {
 		return "", err
 	}
 
-	scanner := bufio.NewScanner(in)
-	if ok := scanner.Scan(); !ok {
-		return "", scanner.Err()
+	input, err := in.ReadString('\n')
+	if err != nil && (err != io.EOF || input == "") {
+		return "", err
 	}
-	input := scanner.Text()
 
-	return input, nil
+	return strings.TrimRight(input, "\r\n"), nil
 }
 
 func UserInInt(buf io.Reader) (int, error) {
@@ -56,7 +56,7 @@ func UserInBool(buf io.ReadWriter) (bool, error) {
 	var input string
 	_, err := fmt.Fscanln(in, &input)
 	if err != nil {
-		if err == unexpectedNewlineErr {
+		if err.Error() == unexpectedNewlineErr.Error() {
 			return false, nil
 		}
 		return false, err
@@ -68,3 +68,27 @@ func UserInBool(buf io.ReadWriter) (bool, error) {
 
 	return false, nil
 }
+
+// UserInDefault prompts for a value, the default value is returned on empty input
+func UserInDefault(buf io.ReadWriter, defaultValue string) (string, error) {
+	if defaultValue != "" {
+		if _, err := fmt.Fprintf(buf, " [%s]", defaultValue); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err := buf.Write([]byte(": ")); err != nil {
+		return "", err
+	}
+
+	input, err := UserIn(buf)
+	if err != nil {
+		return "", err
+	}
+
+	if input = strings.TrimSpace(input); input == "" {
+		return defaultValue, nil
+	}
+
+	return input, nil
+}